@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type apiErrorResponse struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// Writes data as JSON with a strong ETag, answering 304 Not Modified if the client copy matches.
+func (app *application) writeJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+	b = append(b, '\n')
+
+	if status == http.StatusOK && app.notModified(w, r, etag(b), time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func (app *application) apiError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiErrorResponse{
+		Error: apiErrorBody{Status: status, Message: message},
+	})
+}
+
+func (app *application) apiServerError(w http.ResponseWriter, r *http.Request, err error) {
+	app.log(r).Error(
+		"unexpected error",
+		slog.String("err", err.Error()),
+		slog.String("method", r.Method),
+		slog.String("uri", r.URL.RequestURI()),
+	)
+	app.apiError(w, http.StatusInternalServerError, "The server encountered an unexpected error serving your request.")
+}
+
+func (app *application) apiNotFound(w http.ResponseWriter, r *http.Request) {
+	app.apiError(w, http.StatusNotFound, "The requested resource could not be found.")
+}
+
+func (app *application) apiMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	app.apiError(w, http.StatusMethodNotAllowed, fmt.Sprintf("The %s method is not supported by this resource.", r.Method))
+}
+
+func (app *application) apiTooManyRequests(w http.ResponseWriter, r *http.Request) {
+	app.apiError(w, http.StatusTooManyRequests, "Too many requests, please try again later.")
+}
+
+func (app *application) apiClientError(w http.ResponseWriter, r *http.Request, err error) {
+	app.log(r).Warn(
+		"bad request",
+		slog.String("err", err.Error()),
+		slog.String("uri", r.URL.RequestURI()),
+	)
+	app.apiError(w, http.StatusBadRequest, err.Error())
+}
+
+// Allows the read-only API to be called from any origin.
+func (app *application) apiCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		next.ServeHTTP(w, r)
+	})
+}
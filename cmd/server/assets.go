@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+type immutableAssetKey struct{}
+
+// Fingerprints static assets by the hash of their contents, e.g. css/style.css is served as
+// css/style.0123456789ab.css.
+type assetManifest struct {
+	// Maps each asset name to its fingerprinted name.
+	fingerprints map[string]string
+	// Maps each fingerprinted name back to the asset name.
+	names map[string]string
+	// Maps each asset name to its precompressed siblings, by content coding.
+	compressed map[string]map[string]precompressedAsset
+}
+
+// Hashes every file in the static fs.
+func newAssetManifest(static fs.FS) (*assetManifest, error) {
+	manifest := &assetManifest{
+		fingerprints: make(map[string]string),
+		names:        make(map[string]string),
+	}
+	err := fs.WalkDir(static, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		// Precompressed siblings are served in place of their source file.
+		if isPrecompressed(path) {
+			return nil
+		}
+
+		f, err := static.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+
+		hash := hex.EncodeToString(h.Sum(nil))[:12]
+		ext := filepath.Ext(path)
+		fingerprint := strings.TrimSuffix(path, ext) + "." + hash + ext
+
+		manifest.fingerprints[path] = fingerprint
+		manifest.names[fingerprint] = path
+		return nil
+	})
+	return manifest, err
+}
+
+// Returns the URL of the static asset with name. In production, the URL is fingerprinted by
+// the asset contents hash so it can be cached forever.
+func (app *application) assetURL(name string) string {
+	if fingerprint, ok := app.assets.fingerprints[name]; ok && !app.isDev() {
+		return "/static/" + fingerprint
+	}
+	return "/static/" + name
+}
+
+// Resolves fingerprinted asset names back to the file they were built from, marking the
+// request so the response is cached as immutable. Requests must have the /static/ prefix
+// stripped.
+func (app *application) resolveAssets(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := app.assets.names[r.URL.Path]; ok {
+			ctx := context.WithValue(r.Context(), immutableAssetKey{}, true)
+			r = r.Clone(ctx)
+			r.URL.Path = name
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Caches fonts and fingerprinted assets forever, 'no-cache' all others.
+func (app *application) cacheControl(r *http.Request) string {
+	// Always cache fonts
+	if filepath.Ext(r.URL.Path) == ".ttf" {
+		return "public, max-age=31536000, immutable"
+	}
+	if immutable, _ := r.Context().Value(immutableAssetKey{}).(bool); immutable {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
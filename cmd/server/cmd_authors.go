@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"ffss.dev/internal/blog"
+)
+
+const authorsUsage = "usage: server authors list|add|edit|remove [flags]"
+
+// Manages the authors file, usage:
+//
+//	server authors list
+//	server authors add -handle guest -name "Guest" -birth 1990-01-01
+//	server authors edit -handle guest -bio "Writes about Go" -link mastodon=https://hachyderm.io/@guest
+//	server authors remove -handle guest
+//
+// Changes are written to the file and synced to the database on the next server start.
+func runAuthors(args []string) error {
+	if len(args) == 0 {
+		return errors.New(authorsUsage)
+	}
+
+	flags := flag.NewFlagSet("authors "+args[0], flag.ContinueOnError)
+
+	var (
+		path   string
+		author blog.Author
+	)
+	flags.StringVar(&path, "file", "authors.yaml", "Sets the authors file path.")
+	flags.StringVar(&author.Handle, "handle", "", "Sets the author handle.")
+	flags.StringVar(&author.Name, "name", "", "Sets the author name.")
+	flags.StringVar(&author.Bio, "bio", "", "Sets the author bio.")
+	flags.StringVar(&author.Birth, "birth", "", "Sets the author birth date (YYYY-MM-DD).")
+	flags.StringVar(&author.ImageURL, "image-url", "", "Sets the author picture URL.")
+	flags.StringVar(&author.GithubURL, "github-url", "", "Sets the author GitHub profile URL.")
+	flags.StringVar(&author.Pronouns, "pronouns", "", "Sets the author pronouns.")
+	flags.StringVar(&author.Location, "location", "", "Sets the author location.")
+	flags.Func("link", "Adds a profile link as kind=url, kind is one of mastodon, bluesky, linkedin or website. Repeatable.", func(v string) error {
+		kind, u, ok := strings.Cut(v, "=")
+		if !ok {
+			return errors.New("expected kind=url")
+		}
+		author.Links = append(author.Links, blog.AuthorLink{Kind: kind, URL: u})
+		return nil
+	})
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	authors, err := blog.LoadAuthors(path)
+	if err != nil {
+		return err
+	}
+
+	handle := strings.TrimPrefix(author.Handle, "@")
+	idx := slices.IndexFunc(authors, func(a *blog.Author) bool {
+		return a.Handle == handle
+	})
+
+	switch args[0] {
+	case "list":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "HANDLE\tNAME\tBIRTH\tGITHUB")
+		for _, a := range authors {
+			fmt.Fprintf(tw, "@%s\t%s\t%s\t%s\n", a.Handle, a.Name, a.Birth, a.GithubURL)
+		}
+		return tw.Flush()
+
+	case "add":
+		if idx >= 0 {
+			return fmt.Errorf("authors: %w: %q", blog.ErrDuplicateAuthor, handle)
+		}
+		if err := blog.ValidateAuthor(&author); err != nil {
+			return fmt.Errorf("authors: %w", err)
+		}
+		authors = append(authors, &author)
+
+	case "edit":
+		if idx < 0 {
+			return fmt.Errorf("authors: %w: %q", blog.ErrAuthorNotFound, handle)
+		}
+		edited := *authors[idx]
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				edited.Name = author.Name
+			case "bio":
+				edited.Bio = author.Bio
+			case "birth":
+				edited.Birth = author.Birth
+			case "image-url":
+				edited.ImageURL = author.ImageURL
+			case "github-url":
+				edited.GithubURL = author.GithubURL
+			case "pronouns":
+				edited.Pronouns = author.Pronouns
+			case "location":
+				edited.Location = author.Location
+			case "link":
+				edited.Links = author.Links
+			}
+		})
+		if err := blog.ValidateAuthor(&edited); err != nil {
+			return fmt.Errorf("authors: %w", err)
+		}
+		authors[idx] = &edited
+
+	case "remove":
+		if idx < 0 {
+			return fmt.Errorf("authors: %w: %q", blog.ErrAuthorNotFound, handle)
+		}
+		authors = slices.Delete(authors, idx, idx+1)
+
+	default:
+		return errors.New(authorsUsage)
+	}
+
+	return blog.WriteAuthors(path, authors)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = "usage: server config print [flags]"
+
+// Inspects the server configuration, usage:
+//
+//	server config print [-profile dev] [-config blog.yaml] [flags]
+//
+// Print takes the same flags as the server and writes the effective settings as YAML,
+// annotated with where each value came from. The output can be used as a config file: the
+// profile is commented out, as every setting it picks is already listed, and so are set
+// secrets, which are redacted. Fails after printing if the configuration is invalid.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	var cfg config
+	flags := cfg.flagSet("config print", flag.ContinueOnError)
+	sources, err := loadConfig(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	printConfig(os.Stdout, flags, sources, cfg.configPath)
+	return cfg.validate()
+}
+
+// Writes the settings defined on flags as YAML, each annotated with its source.
+func printConfig(w io.Writer, flags *flag.FlagSet, sources map[string]string, configPath string) {
+	if configPath != "" {
+		fmt.Fprintf(w, "# config file: %s\n", configPath)
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		// A config file cannot point to another one.
+		if f.Name == "config" {
+			return
+		}
+		source := sources[f.Name]
+		if source == sourceEnv {
+			source += " " + envName(f.Name)
+		}
+		line := fmt.Sprintf("%s: %s  # %s", f.Name, configValue(f), source)
+		if f.Name == "profile" || secretSettings[f.Name] && f.Value.String() != "" {
+			line = "# " + line
+		}
+		fmt.Fprintln(w, line)
+	})
+}
+
+// Formats the value of f as a YAML scalar.
+func configValue(f *flag.Flag) string {
+	if secretSettings[f.Name] && f.Value.String() != "" {
+		return "<redacted>"
+	}
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch v := getter.Get().(type) {
+		case bool, int, float64:
+			return fmt.Sprint(v)
+		}
+	}
+	b, err := yaml.Marshal(f.Value.String())
+	if err != nil {
+		return fmt.Sprintf("%q", f.Value.String())
+	}
+	return strings.TrimSuffix(string(b), "\n")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"ffss.dev/internal/blog"
+)
+
+// Prints a signed preview link for a single article, usage:
+//
+//	server preview -slug my-draft [-ttl 72h] [-base-url https://example.com] [flags]
+//
+// Besides its own flags, preview takes the same flags as the server and resolves the
+// preview secret and site URL the same way, from the profile, config file and environment.
+func runPreview(args []string) error {
+	var (
+		cfg     config
+		slug    string
+		baseURL string
+		ttl     time.Duration
+	)
+	flags := cfg.flagSet("preview", flag.ContinueOnError)
+	flags.StringVar(&slug, "slug", "", "Sets the slug of the article to preview.")
+	flags.StringVar(&baseURL, "base-url", "", "Sets the base URL prepended to the printed link, defaults to the site URL.")
+	flags.DurationVar(&ttl, "ttl", 72*time.Hour, "Sets how long the preview link is valid for.")
+	if _, err := loadConfig(flags, args, "slug", "base-url", "ttl"); err != nil {
+		return err
+	}
+	secret := cfg.previewSecret
+	if baseURL == "" {
+		baseURL = cfg.site.URL
+	}
+
+	if slug == "" {
+		return errors.New("preview: -slug is required")
+	}
+	if secret == "" {
+		return errors.New("preview: preview-secret is required, set it as for the server")
+	}
+	if ttl <= 0 {
+		return errors.New("preview: -ttl must be positive")
+	}
+
+	expires := time.Now().Add(ttl)
+	token := blog.SignPreview([]byte(secret), slug, expires)
+
+	q := make(url.Values)
+	q.Set("preview", token)
+	fmt.Printf("%s/articles/%s?%s\n", baseURL, url.PathEscape(slug), q.Encode())
+	fmt.Fprintf(os.Stderr, "expires at %s\n", expires.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// Responses smaller than this are not worth compressing.
+	compressMinSize = 1024
+	// Brotli level used for dynamic responses. Higher levels are too slow to run per request.
+	brotliLevel = 5
+)
+
+// Content codings the server can produce, in order of preference.
+var contentCodings = []string{"br", "gzip"}
+
+// Media types worth compressing. Text types are always compressed.
+var compressibleTypes = map[string]bool{
+	"application/atom+xml":   true,
+	"application/javascript": true,
+	"application/json":       true,
+	"application/xml":        true,
+	"image/svg+xml":          true,
+}
+
+// Picks the offered content coding preferred by the request Accept-Encoding header. Ties go
+// to the first offer. Returns an empty string if none is acceptable.
+func acceptEncoding(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return ""
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := encodingQuality(header, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	// Event streams must reach the client as soon as they are flushed.
+	if mediaType == "text/event-stream" {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType]
+}
+
+// Adds value to the Vary header unless it is already listed.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// Buffers the start of the response to decide whether it should be compressed. Responses are
+// compressed once they reach compressMinSize or are flushed, as long as the content type is
+// compressible and no Content-Encoding was set by the handler.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	coding   string
+	status   int
+	buf      []byte
+	enc      io.WriteCloser
+	decided  bool
+	wroteHdr bool
+}
+
+func (c *compressResponseWriter) WriteHeader(status int) {
+	// Informational responses, e.g. 103 Early Hints, go out right away.
+	if status < 200 {
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if c.wroteHdr {
+		return
+	}
+	c.status = status
+	c.wroteHdr = true
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		c.decide(false)
+	}
+}
+
+func (c *compressResponseWriter) Write(b []byte) (int, error) {
+	if !c.wroteHdr {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.decided {
+		c.buf = append(c.buf, b...)
+		if len(c.buf) < compressMinSize {
+			return len(b), nil
+		}
+		if err := c.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if c.enc != nil {
+		return c.enc.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Sends the headers and any buffered bytes, compressing them if compress is set and the
+// response allows it.
+func (c *compressResponseWriter) decide(compress bool) error {
+	c.decided = true
+
+	h := c.Header()
+	if h.Get("Content-Type") == "" && len(c.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(c.buf))
+	}
+	if h.Get("Content-Encoding") == "" && isCompressible(h.Get("Content-Type")) {
+		addVary(h, "Accept-Encoding")
+		compress = compress && c.coding != "" && c.r.Method != http.MethodHead &&
+			c.status != http.StatusNoContent && c.status != http.StatusNotModified &&
+			c.status != http.StatusPartialContent
+	} else {
+		compress = false
+	}
+
+	if compress {
+		h.Set("Content-Encoding", c.coding)
+		h.Del("Content-Length")
+		// The compressed body is a different representation, so the validator is weakened.
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		switch c.coding {
+		case "br":
+			c.enc = brotli.NewWriterLevel(c.ResponseWriter, brotliLevel)
+		case "gzip":
+			c.enc = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+	buf := c.buf
+	c.buf = nil
+	if c.enc != nil {
+		_, err := c.enc.Write(buf)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buf)
+	return err
+}
+
+func (c *compressResponseWriter) Flush() {
+	if !c.wroteHdr {
+		c.WriteHeader(http.StatusOK)
+	}
+	if !c.decided {
+		c.decide(true)
+	}
+	if f, ok := c.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Writes out anything still buffered and terminates the compressed stream.
+func (c *compressResponseWriter) Close() error {
+	if !c.wroteHdr {
+		return nil
+	}
+	if !c.decided {
+		if err := c.decide(false); err != nil {
+			return err
+		}
+	}
+	if c.enc != nil {
+		return c.enc.Close()
+	}
+	return nil
+}
+
+func (c *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Compresses responses with brotli or gzip, according to the request Accept-Encoding header.
+func (app *application) compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &compressResponseWriter{
+			ResponseWriter: w,
+			r:              r,
+			coding:         acceptEncoding(r, contentCodings...),
+			status:         http.StatusOK,
+		}
+		defer func() {
+			if err := cw.Close(); err != nil {
+				app.log(r).Debug("failed to finish compressed response", slog.String("err", err.Error()))
+			}
+		}()
+		next.ServeHTTP(cw, r)
+	})
+}
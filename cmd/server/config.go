@@ -0,0 +1,374 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	profile       string
+	configPath    string
+	addr          string
+	metricsAddr   string
+	dev           bool
+	site          site
+	articles      string
+	static        string
+	views         string
+	dbPath        string
+	authorsPath   string
+	skipInvalid   bool
+	previewSecret string
+	pageCacheMB   int
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+
+	trustedProxies []netip.Prefix
+
+	searchLimit   rateLimit
+	apiLimit      rateLimit
+	articlesLimit rateLimit
+
+	csp               string
+	cspReportOnly     bool
+	hstsMaxAge        time.Duration
+	referrerPolicy    string
+	permissionsPolicy string
+
+	tracing          string
+	otlpEndpoint     string
+	traceSampleRatio float64
+}
+
+// Where a setting got its value from, see [loadConfig].
+const (
+	sourceDefault = "default"
+	sourceProfile = "profile"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Prefix of the environment variable of each setting, e.g. BLOG_DB_PATH for -db-path.
+const envPrefix = "BLOG_"
+
+// Settings overridden by each profile. The flag defaults match the production profile, so a
+// server started without any configuration never runs in dev mode. Production does not
+// require site-url, absolute links fall back to the request host with a warning on start.
+var profiles = map[string]map[string]string{
+	"production": {
+		"dev":                 "false",
+		"trusted-proxies":     defaultTrustedProxies,
+		"read-timeout":        "5s",
+		"write-timeout":       "10s",
+		"idle-timeout":        "1m0s",
+		"hsts-max-age":        "8760h0m0s",
+		"page-cache-size":     "32",
+		"search-rate-limit":   "30/1m",
+		"api-rate-limit":      "120/1m",
+		"articles-rate-limit": "60/1m",
+	},
+	"dev": {
+		"dev":                 "true",
+		"trusted-proxies":     defaultTrustedProxies,
+		"hsts-max-age":        "0",
+		"page-cache-size":     "0",
+		"search-rate-limit":   "0",
+		"api-rate-limit":      "0",
+		"articles-rate-limit": "0",
+	},
+}
+
+// Settings never printed in clear by the config print command.
+var secretSettings = map[string]bool{
+	"preview-secret": true,
+}
+
+// Defines a flag for every setting of cfg on a new flag set.
+func (cfg *config) flagSet(name string, handling flag.ErrorHandling) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, handling)
+	flags.StringVar(&cfg.profile, "profile", "production", "Sets the defaults profile, one of production or dev.")
+	flags.StringVar(&cfg.configPath, "config", "", "Sets the YAML config file path.")
+	flags.StringVar(&cfg.addr, "addr", ":4000", "Sets the HTTP server listen address.")
+	flags.StringVar(&cfg.metricsAddr, "metrics-addr", ":8080", "Sets the metrics HTTP listen address. Empty disables metrics.")
+	flags.BoolVar(&cfg.dev, "dev", false, "Sets the application in development mode.")
+	flags.StringVar(&cfg.site.URL, "site-url", "", "Sets the public URL of the site used in absolute links, defaults to the request host.")
+	flags.StringVar(&cfg.site.Title, "site-title", "ffss.dev", "Sets the site name shown in the header and page titles.")
+	flags.StringVar(&cfg.site.Description, "site-description", "Some golang ideas I have.", "Sets the default page description.")
+	flags.StringVar(&cfg.site.Author, "site-author", "", "Sets the default author name of pages.")
+	flags.StringVar(&cfg.site.Language, "site-language", "en", "Sets the language of pages, as a BCP 47 tag.")
+	flags.StringVar(&cfg.site.Twitter, "site-twitter", "", "Sets the X/Twitter handle of the site, e.g. @ffss92.")
+	flags.StringVar(&cfg.site.Image, "site-image", "images/android-chrome-512x512.png", "Sets the image shared for pages without one, a static asset name or an absolute URL.")
+	flags.StringVar(&cfg.site.Mastodon, "site-mastodon", "", "Sets the Mastodon profile URL of the site, linked with rel=me.")
+	flags.StringVar(&cfg.articles, "articles", "articles", "Sets the articles dir.")
+	flags.StringVar(&cfg.static, "static", "web/static", "Sets the static dir.")
+	flags.StringVar(&cfg.views, "views", "web/views", "Sets the views dir.")
+	flags.StringVar(&cfg.dbPath, "db-path", "blog.db", "Sets the sqlite database path.")
+	flags.StringVar(&cfg.authorsPath, "authors", "authors.yaml", "Sets the authors file synced to the database on start.")
+	flags.BoolVar(&cfg.skipInvalid, "skip-invalid", false, "Skips articles with invalid front matter instead of failing.")
+	flags.IntVar(&cfg.pageCacheMB, "page-cache-size", 32, "Sets the rendered page cache size in MB, 0 disables it. Only used in production.")
+	flags.StringVar(&cfg.previewSecret, "preview-secret", "", "Sets the secret used to sign draft preview links.")
+	flags.DurationVar(&cfg.readTimeout, "read-timeout", 5*time.Second, "Sets the HTTP server read timeout.")
+	flags.DurationVar(&cfg.writeTimeout, "write-timeout", 10*time.Second, "Sets the HTTP server write timeout.")
+	flags.DurationVar(&cfg.idleTimeout, "idle-timeout", time.Minute, "Sets the HTTP server keep-alive idle timeout.")
+	flags.StringVar(&cfg.csp, "csp", defaultCSP, "Sets the Content-Security-Policy, {nonce} is replaced by the request nonce. Empty disables it.")
+	flags.BoolVar(&cfg.cspReportOnly, "csp-report-only", false, "Only reports Content-Security-Policy violations instead of enforcing it.")
+	flags.DurationVar(&cfg.hstsMaxAge, "hsts-max-age", 365*24*time.Hour, "Sets the Strict-Transport-Security max age, 0 disables it. Only used in production.")
+	flags.StringVar(&cfg.referrerPolicy, "referrer-policy", defaultReferrerPolicy, "Sets the Referrer-Policy header.")
+	flags.StringVar(&cfg.permissionsPolicy, "permissions-policy", defaultPermissionsPolicy, "Sets the Permissions-Policy header.")
+	flags.StringVar(&cfg.tracing, "tracing", "", "Sets the trace exporter, one of otlp or stdout. Empty disables tracing.")
+	flags.StringVar(&cfg.otlpEndpoint, "otlp-endpoint", "", "Sets the OTLP/HTTP traces endpoint URL, defaults to the OTEL_EXPORTER_OTLP_* environment.")
+	flags.Float64Var(&cfg.traceSampleRatio, "trace-sample-ratio", 1, "Sets the ratio of traces sampled, from 0 to 1.")
+	flags.Var(newRateLimitValue(&cfg.searchLimit, "30/1m"), "search-rate-limit", "Sets the per client rate limit of /api/search as <requests>/<duration>, 0 disables it.")
+	flags.Var(newRateLimitValue(&cfg.apiLimit, "120/1m"), "api-rate-limit", "Sets the per client rate limit of the JSON API as <requests>/<duration>, 0 disables it.")
+	flags.Var(newRateLimitValue(&cfg.articlesLimit, "60/1m"), "articles-rate-limit", "Sets the per client rate limit of article pages as <requests>/<duration>, 0 disables it.")
+	flags.Var(newProxiesValue(&cfg.trustedProxies, defaultTrustedProxies), "trusted-proxies", "Sets the comma separated proxy CIDRs whose forwarding headers are trusted.")
+	return flags
+}
+
+// Parses args into the settings defined on flags, layering values from lowest to highest
+// precedence: flag defaults, profile, config file, environment and command line. Returns
+// the source of each setting. The local flags belong to a subcommand rather than to the
+// server, they are only set from the command line.
+func loadConfig(flags *flag.FlagSet, args []string, local ...string) (map[string]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	sources := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		sources[f.Name] = sourceFlag
+	})
+
+	// The config file cannot point to another one.
+	if err := setFromEnv(flags, sources, "config"); err != nil {
+		return nil, err
+	}
+	var file map[string]string
+	if path := flags.Lookup("config").Value.String(); path != "" {
+		var err error
+		file, err = readConfigFile(flags, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range local {
+			if _, ok := file[name]; ok {
+				return nil, fmt.Errorf("config file %s: unknown setting %q", path, name)
+			}
+		}
+	}
+
+	resolve := func(name string, defaults map[string]string) error {
+		if _, ok := sources[name]; ok {
+			return nil
+		}
+		if err := setFromEnv(flags, sources, name); err != nil || sources[name] != "" {
+			return err
+		}
+		if v, ok := file[name]; ok {
+			sources[name] = sourceFile
+			return setConfigValue(flags, sourceFile, name, v)
+		}
+		if v, ok := defaults[name]; ok {
+			sources[name] = sourceProfile
+			return setConfigValue(flags, sourceProfile, name, v)
+		}
+		sources[name] = sourceDefault
+		return nil
+	}
+
+	// The profile picks the defaults of every other setting, so it is resolved first.
+	if err := resolve("profile", nil); err != nil {
+		return nil, err
+	}
+	profile := flags.Lookup("profile").Value.String()
+	defaults, ok := profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q, expected production or dev", profile)
+	}
+
+	var errs []error
+	flags.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(local, f.Name) {
+			return
+		}
+		errs = append(errs, resolve(f.Name, defaults))
+	})
+	return sources, errors.Join(errs...)
+}
+
+// Returns the environment variable of a setting, e.g. BLOG_DB_PATH for db-path.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Sets the named setting from its environment variable, if present. An empty variable is
+// still applied, so it can clear a default.
+func setFromEnv(flags *flag.FlagSet, sources map[string]string, name string) error {
+	if _, ok := sources[name]; ok {
+		return nil
+	}
+	v, ok := os.LookupEnv(envName(name))
+	if !ok {
+		return nil
+	}
+	sources[name] = sourceEnv
+	return setConfigValue(flags, envName(name), name, v)
+}
+
+func setConfigValue(flags *flag.FlagSet, source, name, value string) error {
+	if err := flags.Set(name, value); err != nil {
+		return fmt.Errorf("%s: invalid value %q for %s: %w", source, value, name, err)
+	}
+	return nil
+}
+
+// Reads a YAML config file, a map from setting names, as used by the flags, to values.
+// Lists are joined with commas, e.g. for trusted-proxies.
+func readConfigFile(flags *flag.FlagSet, path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		if name == "config" || flags.Lookup(name) == nil {
+			return nil, fmt.Errorf("config file %s: unknown setting %q", path, name)
+		}
+		switch v := v.(type) {
+		case nil:
+			values[name] = ""
+		case []any:
+			parts := make([]string, len(v))
+			for i, part := range v {
+				parts[i] = fmt.Sprint(part)
+			}
+			values[name] = strings.Join(parts, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("config file %s: %s must be a scalar or a list", path, name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// Reports invalid combinations and values that flag parsing lets through.
+func (cfg *config) validate() error {
+	var errs []error
+	if cfg.addr == "" {
+		errs = append(errs, errors.New("addr must not be empty"))
+	}
+	if cfg.site.URL != "" {
+		u, err := url.Parse(cfg.site.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, fmt.Errorf("site-url must be an absolute http or https URL, got %q", cfg.site.URL))
+		}
+	}
+	if cfg.site.Title == "" {
+		errs = append(errs, errors.New("site-title must not be empty"))
+	}
+	if cfg.site.Language == "" {
+		errs = append(errs, errors.New("site-language must not be empty"))
+	}
+	if cfg.site.Twitter != "" && !strings.HasPrefix(cfg.site.Twitter, "@") {
+		errs = append(errs, fmt.Errorf("site-twitter must start with @, got %q", cfg.site.Twitter))
+	}
+	if cfg.site.Mastodon != "" {
+		if u, err := url.Parse(cfg.site.Mastodon); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("site-mastodon must be an absolute https URL, got %q", cfg.site.Mastodon))
+		}
+	}
+	if cfg.pageCacheMB < 0 {
+		errs = append(errs, errors.New("page-cache-size must not be negative"))
+	}
+	if cfg.readTimeout < 0 || cfg.writeTimeout < 0 || cfg.idleTimeout < 0 {
+		errs = append(errs, errors.New("read-timeout, write-timeout and idle-timeout must not be negative"))
+	}
+	if cfg.hstsMaxAge < 0 {
+		errs = append(errs, errors.New("hsts-max-age must not be negative"))
+	}
+	switch cfg.tracing {
+	case "", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("tracing must be otlp, stdout or empty, got %q", cfg.tracing))
+	}
+	if cfg.traceSampleRatio < 0 || cfg.traceSampleRatio > 1 {
+		errs = append(errs, errors.New("trace-sample-ratio must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// A rate limit flag, keeping the spec it was set from.
+type rateLimitValue struct {
+	rl   *rateLimit
+	spec string
+}
+
+func newRateLimitValue(rl *rateLimit, spec string) *rateLimitValue {
+	v := &rateLimitValue{rl: rl}
+	if err := v.Set(spec); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *rateLimitValue) String() string {
+	return v.spec
+}
+
+func (v *rateLimitValue) Set(s string) error {
+	rl, err := parseRateLimit(s)
+	if err != nil {
+		return err
+	}
+	*v.rl, v.spec = rl, s
+	return nil
+}
+
+// A trusted proxies flag, keeping the list it was set from.
+type proxiesValue struct {
+	prefixes *[]netip.Prefix
+	list     string
+}
+
+func newProxiesValue(prefixes *[]netip.Prefix, list string) *proxiesValue {
+	v := &proxiesValue{prefixes: prefixes}
+	if err := v.Set(list); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *proxiesValue) String() string {
+	return v.list
+}
+
+func (v *proxiesValue) Set(s string) error {
+	prefixes, err := parseTrustedProxies(s)
+	if err != nil {
+		return err
+	}
+	*v.prefixes, v.list = prefixes, s
+	return nil
+}
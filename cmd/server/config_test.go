@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes contents to a config file in a temporary dir and returns its path.
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blog.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		file       string
+		env        map[string]string
+		want       int
+		wantSource string
+	}{
+		{name: "production profile", want: 32, wantSource: sourceProfile},
+		{name: "dev profile", args: []string{"-profile", "dev"}, want: 0, wantSource: sourceProfile},
+		{
+			name:       "file over profile",
+			args:       []string{"-profile", "dev"},
+			file:       "page-cache-size: 8\n",
+			want:       8,
+			wantSource: sourceFile,
+		},
+		{
+			name:       "env over file",
+			args:       []string{"-profile", "dev"},
+			file:       "page-cache-size: 8\n",
+			env:        map[string]string{"BLOG_PAGE_CACHE_SIZE": "16"},
+			want:       16,
+			wantSource: sourceEnv,
+		},
+		{
+			name:       "flag over env",
+			args:       []string{"-profile", "dev", "-page-cache-size", "24"},
+			file:       "page-cache-size: 8\n",
+			env:        map[string]string{"BLOG_PAGE_CACHE_SIZE": "16"},
+			want:       24,
+			wantSource: sourceFlag,
+		},
+		{
+			name:       "profile from file",
+			file:       "profile: dev\n",
+			want:       0,
+			wantSource: sourceProfile,
+		},
+		{
+			name:       "profile from env",
+			env:        map[string]string{"BLOG_PROFILE": "dev"},
+			want:       0,
+			wantSource: sourceProfile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfigFile(t, tt.file)}, args...)
+			}
+
+			var cfg config
+			sources, err := loadConfig(cfg.flagSet("test", flag.ContinueOnError), args)
+			if err != nil {
+				t.Fatalf("loadConfig() error: %v", err)
+			}
+			if cfg.pageCacheMB != tt.want {
+				t.Errorf("page-cache-size = %d, want %d", cfg.pageCacheMB, tt.want)
+			}
+			if got := sources["page-cache-size"]; got != tt.wantSource {
+				t.Errorf("page-cache-size source = %q, want %q", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	var cfg config
+	sources, err := loadConfig(cfg.flagSet("test", flag.ContinueOnError), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.addr != ":4000" || sources["addr"] != sourceDefault {
+		t.Errorf("addr = %q from %q, want :4000 from %q", cfg.addr, sources["addr"], sourceDefault)
+	}
+	if cfg.profile != "production" || cfg.dev {
+		t.Errorf("profile = %q, dev = %v, want production without dev mode", cfg.profile, cfg.dev)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("validate() error: %v", err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{name: "unknown profile", args: []string{"-profile", "staging"}, want: `unknown profile "staging"`},
+		{name: "unknown file key", file: "listen: :4000\n", want: `unknown setting "listen"`},
+		{name: "config key in file", file: "config: other.yaml\n", want: `unknown setting "config"`},
+		{name: "nested file value", file: "site-url:\n  host: ffss.dev\n", want: "must be a scalar or a list"},
+		{name: "invalid file value", file: "page-cache-size: lots\n", want: "file: invalid value"},
+		{name: "invalid env value", env: map[string]string{"BLOG_READ_TIMEOUT": "soon"}, want: "BLOG_READ_TIMEOUT: invalid value"},
+		{name: "invalid rate limit", file: "api-rate-limit: fast\n", want: "invalid value"},
+		{name: "invalid flag value", args: []string{"-trusted-proxies", "nope"}, want: "invalid trusted proxy"},
+		{name: "unexpected argument", args: []string{"serve"}, want: `unexpected argument "serve"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeConfigFile(t, tt.file)}, args...)
+			}
+
+			var cfg config
+			flags := cfg.flagSet("test", flag.ContinueOnError)
+			flags.SetOutput(new(bytes.Buffer))
+			_, err := loadConfig(flags, args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadConfig() = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigLocalFlags(t *testing.T) {
+	t.Setenv("BLOG_SLUG", "from-env")
+
+	var (
+		cfg  config
+		slug string
+	)
+	flags := cfg.flagSet("test", flag.ContinueOnError)
+	flags.StringVar(&slug, "slug", "", "")
+	if _, err := loadConfig(flags, nil, "slug"); err != nil {
+		t.Fatal(err)
+	}
+	if slug != "" {
+		t.Errorf("slug = %q, want it only set from the command line", slug)
+	}
+
+	path := writeConfigFile(t, "slug: from-file\n")
+	flags = cfg.flagSet("test", flag.ContinueOnError)
+	flags.StringVar(&slug, "slug", "", "")
+	if _, err := loadConfig(flags, []string{"-config", path}, "slug"); err == nil {
+		t.Error("loadConfig() accepted a local flag in the config file")
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	t.Setenv("BLOG_PREVIEW_SECRET", "hunter2")
+
+	var cfg config
+	flags := cfg.flagSet("test", flag.ContinueOnError)
+	sources, err := loadConfig(flags, []string{"-profile", "dev", "-site-url", "https://ffss.dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	printConfig(&buf, flags, sources, "")
+	out := buf.String()
+
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("printed config leaks the preview secret:\n%s", out)
+	}
+	for _, want := range []string{
+		"# preview-secret: <redacted>  # env BLOG_PREVIEW_SECRET\n",
+		"# profile: dev  # flag\n",
+		"site-url: https://ffss.dev  # flag\n",
+		"page-cache-size: 0  # profile\n",
+		"addr: :4000  # default\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printed config is missing %q:\n%s", want, out)
+		}
+	}
+
+	// The output, less the commented out settings, loads back to the same values.
+	path := writeConfigFile(t, out)
+	t.Setenv("BLOG_PREVIEW_SECRET", "")
+	var loaded config
+	loadedSources, err := loadConfig(loaded.flagSet("test", flag.ContinueOnError), []string{"-config", path})
+	if err != nil {
+		t.Fatalf("loading the printed config: %v", err)
+	}
+	if loaded.site.URL != cfg.site.URL || loaded.pageCacheMB != cfg.pageCacheMB || loaded.dev != cfg.dev {
+		t.Errorf("loaded config = %+v, want %+v", loaded, cfg)
+	}
+	if got := loadedSources["page-cache-size"]; got != sourceFile {
+		t.Errorf("page-cache-size source = %q, want %q", got, sourceFile)
+	}
+}
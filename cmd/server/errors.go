@@ -11,20 +11,21 @@ type errorPage struct {
 	StatusCode int
 	StatusText string
 	Message    string
+	RequestID  string
 }
 
 func (app *application) renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
-	w.WriteHeader(status)
-	app.render(w, r, "error", errorPage{
+	app.render(w, r, status, "error", errorPage{
 		basePage:   app.newBasePage(r, fmt.Sprint(status)),
 		StatusCode: status,
 		StatusText: http.StatusText(status),
 		Message:    message,
+		RequestID:  requestID(r),
 	})
 }
 
 func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Error(
+	app.log(r).Error(
 		"unexpected error",
 		slog.String("err", err.Error()),
 		slog.String("method", r.Method),
@@ -38,10 +39,14 @@ func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
 }
 
 func (app *application) clientError(w http.ResponseWriter, r *http.Request, err error) {
-	app.logger.Warn(
+	app.log(r).Warn(
 		"bad request",
 		slog.String("err", err.Error()),
 		slog.String("uri", r.URL.RequestURI()),
 	)
 	app.renderError(w, r, http.StatusBadRequest, "Invalid or malformed request.")
 }
+
+func (app *application) tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	app.renderError(w, r, http.StatusTooManyRequests, "You are making too many requests, please try again later.")
+}
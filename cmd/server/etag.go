@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Returns a strong ETag for the response body b.
+func etag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Reports whether the If-None-Match request header matches etag. Weak validators are compared
+// ignoring the W/ prefix, as required for If-None-Match.
+func etagMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Sets the ETag and Last-Modified validators and reports whether the request preconditions
+// match them, in which case 304 Not Modified was written. If-Modified-Since is only evaluated
+// without If-None-Match, as required by RFC 9110. A zero modTime omits Last-Modified.
+func (app *application) notModified(w http.ResponseWriter, r *http.Request, etag string, modTime time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	// Without it, Last-Modified would allow browsers to heuristically cache pages.
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	var match bool
+	if r.Header.Get("If-None-Match") != "" {
+		match = etagMatch(r, etag)
+	} else if !modTime.IsZero() {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		match = err == nil && !modTime.Truncate(time.Second).After(since)
+	}
+	if !match {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// Returns the latest of times.
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, candidate := range times {
+		if candidate.After(t) {
+			t = candidate
+		}
+	}
+	return t
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ffss.dev/internal/blog"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	apiDefaultPageSize = 10
+	apiMaxPageSize     = 100
+)
+
+type apiArticle struct {
+	Slug        string     `json:"slug"`
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	Subtitle    string     `json:"subtitle"`
+	Authors     []string   `json:"authors"`
+	Tags        []string   `json:"tags"`
+	Date        string     `json:"date"`
+	PublishedAt time.Time  `json:"published_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	PageViews   int        `json:"page_views"`
+}
+
+type apiArticleDetail struct {
+	apiArticle
+	HTML string         `json:"html"`
+	TOC  []blog.Heading `json:"toc"`
+}
+
+type apiPagination struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+type apiArticleList struct {
+	Articles   []apiArticle  `json:"articles"`
+	Pagination apiPagination `json:"pagination"`
+}
+
+func newAPIArticle(base string, article *blog.Article) apiArticle {
+	res := apiArticle{
+		Slug:        article.Slug,
+		URL:         base + "/articles/" + article.Slug,
+		Title:       article.Title,
+		Subtitle:    article.Subtitle,
+		Authors:     article.Authors,
+		Tags:        article.Tags,
+		Date:        article.Date.Format(time.DateOnly),
+		PublishedAt: article.PublishedAt(),
+		PageViews:   article.PageViews,
+	}
+	if updated := article.UpdatedAt(); !updated.IsZero() {
+		res.UpdatedAt = &updated
+	}
+	if res.Tags == nil {
+		res.Tags = []string{}
+	}
+	return res
+}
+
+// Parses a positive integer query parameter, returning def if it is not set.
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+	return n, nil
+}
+
+func (app *application) handleAPIArticleIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		sort := q.Get("sort")
+		switch sort {
+		case "":
+			sort = "date"
+		case "date", "popular":
+		default:
+			app.apiClientError(w, r, errors.New("sort must be one of date or popular"))
+			return
+		}
+
+		page, err := queryInt(r, "page", 1)
+		if err != nil {
+			app.apiClientError(w, r, err)
+			return
+		}
+		perPage, err := queryInt(r, "per_page", apiDefaultPageSize)
+		if err != nil {
+			app.apiClientError(w, r, err)
+			return
+		}
+		if perPage > apiMaxPageSize {
+			app.apiClientError(w, r, fmt.Errorf("per_page must be at most %d", apiMaxPageSize))
+			return
+		}
+
+		list, err := app.blog.ListArticles(r.Context(), blog.ListArticlesOptions{
+			Sort:     sort,
+			Tag:      q.Get("tag"),
+			Author:   q.Get("author"),
+			Page:     page,
+			PageSize: perPage,
+		})
+		if err != nil {
+			app.apiServerError(w, r, err)
+			return
+		}
+
+		base := app.baseURL(r)
+		res := apiArticleList{
+			Articles: make([]apiArticle, 0, len(list.Articles)),
+			Pagination: apiPagination{
+				Page:       list.Page,
+				PerPage:    list.PageSize,
+				Total:      list.Total,
+				TotalPages: list.TotalPages(),
+			},
+		}
+		for _, article := range list.Articles {
+			res.Articles = append(res.Articles, newAPIArticle(base, article))
+		}
+
+		app.writeJSON(w, r, http.StatusOK, res)
+	}
+}
+
+func (app *application) handleAPIArticleShow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		article, err := app.blog.GetArticle(r.Context(), chi.URLParam(r, "slug"))
+		if err != nil {
+			switch {
+			case errors.Is(err, blog.ErrArticleNotFound):
+				app.apiNotFound(w, r)
+			default:
+				app.apiServerError(w, r, err)
+			}
+			return
+		}
+
+		app.writeJSON(w, r, http.StatusOK, apiArticleDetail{
+			apiArticle: newAPIArticle(app.baseURL(r), article),
+			HTML:       string(article.Content),
+			TOC:        article.TOC,
+		})
+	}
+}
+
+func (app *application) handleAPIAuthorShow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		author, err := app.blog.GetAuthor(r.Context(), chi.URLParam(r, "handle"))
+		if err != nil {
+			switch {
+			case errors.Is(err, blog.ErrAuthorNotFound):
+				app.apiNotFound(w, r)
+			default:
+				app.apiServerError(w, r, err)
+			}
+			return
+		}
+
+		app.writeJSON(w, r, http.StatusOK, author)
+	}
+}
+
+func (app *application) handleAPITagIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags, err := app.blog.ListTags(r.Context())
+		if err != nil {
+			app.apiServerError(w, r, err)
+			return
+		}
+
+		app.writeJSON(w, r, http.StatusOK, map[string]any{"tags": tags})
+	}
+}
@@ -2,17 +2,28 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"ffss.dev/internal/blog"
 	"github.com/go-chi/chi/v5"
 )
 
+const articlesPageSize = 10
+
 type articleIndexPage struct {
 	basePage
 	Sort     string
+	Tag      string
 	Articles []*blog.Article
+	PrevURL  string
+	NextURL  string
+	blog.Pagination
 }
 
 func (app *application) handleArticleIndex() http.HandlerFunc {
@@ -26,60 +37,198 @@ func (app *application) handleArticleIndex() http.HandlerFunc {
 			sort = "date"
 		}
 
-		articles, err := app.blog.ListArticles(r.Context(), sort)
-		if err != nil {
-			app.serverError(w, r, err)
+		page := 1
+		if v := r.URL.Query().Get("page"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				app.clientError(w, r, fmt.Errorf("invalid page %q", v))
+				return
+			}
+			page = n
+		}
+
+		tag := r.URL.Query().Get("tag")
+		cacheKey := app.pageCacheKey(r, "articles/index", sort, tag, strconv.Itoa(page))
+		if app.serveCachedPage(w, r, cacheKey) {
 			return
 		}
 
-		app.render(w, r, "articles/index", articleIndexPage{
-			basePage: app.newBasePage(r, "Articles"),
-			Articles: articles,
+		list, err := app.blog.ListArticles(r.Context(), blog.ListArticlesOptions{
 			Sort:     sort,
+			Tag:      tag,
+			Page:     page,
+			PageSize: articlesPageSize,
 		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		if page > list.TotalPages() {
+			app.notFound(w, r)
+			return
+		}
+
+		base := app.newBasePage(r, "Articles")
+		base.Meta.Canonical = base.Site.URL + canonicalIndexURL(sort, tag, page)
+		if sort == "date" && len(list.Articles) > 0 {
+			base.modTime = feedUpdated(list.Articles)
+		}
+
+		data := articleIndexPage{
+			basePage:   base,
+			Articles:   list.Articles,
+			Sort:       sort,
+			Tag:        tag,
+			Pagination: list.Pagination,
+		}
+		if list.HasPrev() {
+			data.PrevURL = articleIndexURL(sort, tag, list.PrevPage())
+		}
+		if list.HasNext() {
+			data.NextURL = articleIndexURL(sort, tag, list.NextPage())
+		}
+
+		app.renderCached(w, r, http.StatusOK, cacheKey, "articles/index", data)
+	}
+}
+
+// Builds the article index URL for the given page, keeping the sort and tag filters.
+func articleIndexURL(sort, tag string, page int) string {
+	q := make(url.Values)
+	q.Set("sort", sort)
+	if tag != "" {
+		q.Set("tag", tag)
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	return "/articles?" + q.Encode()
+}
+
+// Like [articleIndexURL], leaving out the default sort so the first page is just /articles.
+func canonicalIndexURL(sort, tag string, page int) string {
+	if sort == "date" && tag == "" && page == 1 {
+		return "/articles"
 	}
+	return articleIndexURL(sort, tag, page)
 }
 
 type articleShowPage struct {
 	basePage
 	Article *blog.Article
-	Author  *blog.Author
+	Authors []*blog.Author
 }
 
 func (app *application) handleArticleShow() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		slug := chi.URLParam(r, "slug")
-		article, err := app.blog.GetArticle(r.Context(), slug)
+		preview := r.URL.Query().Get("preview")
+
+		// The .md alias always serves the source, otherwise the format is negotiated.
+		format, ok := "text/markdown", false
+		if slug, ok = strings.CutSuffix(slug, ".md"); !ok {
+			format = negotiate(r, "text/html", "text/markdown", "application/json")
+			w.Header().Add("Vary", "Accept")
+		}
+
+		var cacheKey string
+		if preview == "" && format == "text/html" {
+			cacheKey = app.pageCacheKey(r, "articles/show")
+			if app.serveCachedPage(w, r, cacheKey) {
+				app.savePageview(r, slug)
+				return
+			}
+		}
+
+		var (
+			article *blog.Article
+			err     error
+		)
+		if preview != "" {
+			article, err = app.blog.PreviewArticle(r.Context(), slug, preview)
+		} else {
+			article, err = app.blog.GetArticle(r.Context(), slug)
+		}
 		if err != nil {
 			switch {
 			case errors.Is(err, blog.ErrArticleNotFound):
 				app.notFound(w, r)
+			case errors.Is(err, blog.ErrInvalidPreview), errors.Is(err, blog.ErrExpiredPreview):
+				app.renderError(w, r, http.StatusForbidden, "The preview link is invalid or has expired.")
 			default:
 				app.serverError(w, r, err)
 			}
 			return
 		}
 
-		author, err := app.blog.GetAuthor(r.Context(), article.Author)
-		if err != nil {
-			switch {
-			case errors.Is(err, blog.ErrAuthorNotFound):
-				app.notFound(w, r)
-			default:
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-			}
+		if preview != "" {
+			// Previews must not be indexed, cached by shared caches or counted as views.
+			w.Header().Set("Cache-Control", "private")
+			w.Header().Set("X-Robots-Tag", "noindex")
+		}
+
+		switch format {
+		case "text/markdown":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = io.WriteString(w, article.RawContent)
+			return
+		case "application/json":
+			app.writeJSON(w, r, http.StatusOK, apiArticleDetail{
+				apiArticle: newAPIArticle(app.baseURL(r), article),
+				HTML:       string(article.Content),
+				TOC:        article.TOC,
+			})
 			return
 		}
 
-		err = app.blog.SavePageview(r.Context(), slug, r.RemoteAddr, r.UserAgent(), r.Referer())
-		if err != nil {
-			app.logger.Error("failed to save pageview", slog.String("err", err.Error()))
+		authors := app.articleAuthors(r, article)
+
+		base := app.newBasePage(r, article.Title)
+		base.modTime = articleUpdated(article)
+		articleMeta(&base, article, authors)
+		if preview != "" {
+			base.NoIndex = true
+		} else {
+			app.savePageview(r, slug)
 		}
 
-		app.render(w, r, "articles/show", articleShowPage{
-			basePage: app.newBasePage(r, article.Title),
+		app.renderCached(w, r, http.StatusOK, cacheKey, "articles/show", articleShowPage{
+			basePage: base,
 			Article:  article,
-			Author:   author,
+			Authors:  authors,
 		})
 	}
 }
+
+func (app *application) savePageview(r *http.Request, slug string) {
+	app.metrics.IncArticleView(slug)
+	// Failures are only logged, they must not break the page.
+	err := app.blog.SavePageview(r.Context(), slug, r.RemoteAddr, r.UserAgent(), r.Referer())
+	if err != nil {
+		app.log(r).Error(
+			"failed to save pageview",
+			slog.String("err", err.Error()),
+			slog.String("slug", slug),
+		)
+	}
+}
+
+// Looks up the article authors. Authors that fail to load are logged and replaced by a
+// placeholder with only their handle, so the article still renders.
+func (app *application) articleAuthors(r *http.Request, article *blog.Article) []*blog.Author {
+	authors := make([]*blog.Author, 0, len(article.Authors))
+	for _, handle := range article.Authors {
+		author, err := app.blog.GetAuthor(r.Context(), handle)
+		if err != nil {
+			app.log(r).Warn(
+				"failed to get article author",
+				slog.String("err", err.Error()),
+				slog.String("slug", article.Slug),
+				slog.String("handle", handle),
+			)
+			author = &blog.Author{Handle: handle, Name: "@" + handle}
+		}
+		authors = append(authors, author)
+	}
+	return authors
+}
@@ -10,12 +10,18 @@ import (
 
 type authorPage struct {
 	basePage
-	Author *blog.Author
+	Author   *blog.Author
+	Articles []*blog.Article
 }
 
 func (app *application) handleAuthorShow() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		handle := chi.URLParam(r, "handle")
+		cacheKey := app.pageCacheKey(r, "authors/show")
+		if app.serveCachedPage(w, r, cacheKey) {
+			return
+		}
+
 		author, err := app.blog.GetAuthor(r.Context(), handle)
 		if err != nil {
 			switch {
@@ -27,9 +33,22 @@ func (app *application) handleAuthorShow() http.HandlerFunc {
 			return
 		}
 
-		app.render(w, r, "authors/show", authorPage{
-			basePage: app.newBasePage(r, author.Name),
+		list, err := app.blog.ListArticles(r.Context(), blog.ListArticlesOptions{
+			Sort:   "date",
+			Author: author.Handle,
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		base := app.newBasePage(r, author.Name)
+		authorMeta(&base, author)
+
+		app.renderCached(w, r, http.StatusOK, cacheKey, "authors/show", authorPage{
+			basePage: base,
 			Author:   author,
+			Articles: list.Articles,
 		})
 	}
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+const maxCSPReportBytes = 64 << 10
+
+// A Content-Security-Policy violation, normalized from either report format.
+type cspViolation struct {
+	DocumentURL string
+	BlockedURL  string
+	Directive   string
+	SourceFile  string
+	Line        int
+	Column      int
+	Disposition string
+	Sample      string
+}
+
+// Body of the report-uri format, sent as application/csp-report.
+type cspReportURIBody struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		SourceFile         string `json:"source-file"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		Disposition        string `json:"disposition"`
+		ScriptSample       string `json:"script-sample"`
+	} `json:"csp-report"`
+}
+
+// Body of the Reporting API format, sent as application/reports+json.
+type cspReportToBody []struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		Disposition        string `json:"disposition"`
+		Sample             string `json:"sample"`
+	} `json:"body"`
+}
+
+// Logs Content-Security-Policy violation reports sent by browsers.
+func (app *application) handleCSPReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxCSPReportBytes)
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+		var violations []cspViolation
+		switch mediaType {
+		case "application/csp-report", "application/json":
+			var body cspReportURIBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				app.apiClientError(w, r, errors.New("malformed csp report"))
+				return
+			}
+			report := body.Report
+			directive := report.EffectiveDirective
+			if directive == "" {
+				directive = report.ViolatedDirective
+			}
+			violations = append(violations, cspViolation{
+				DocumentURL: report.DocumentURI,
+				BlockedURL:  report.BlockedURI,
+				Directive:   directive,
+				SourceFile:  report.SourceFile,
+				Line:        report.LineNumber,
+				Column:      report.ColumnNumber,
+				Disposition: report.Disposition,
+				Sample:      report.ScriptSample,
+			})
+		case "application/reports+json":
+			var body cspReportToBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				app.apiClientError(w, r, errors.New("malformed csp report"))
+				return
+			}
+			for _, report := range body {
+				if report.Type != "csp-violation" {
+					continue
+				}
+				violations = append(violations, cspViolation{
+					DocumentURL: report.Body.DocumentURL,
+					BlockedURL:  report.Body.BlockedURL,
+					Directive:   report.Body.EffectiveDirective,
+					SourceFile:  report.Body.SourceFile,
+					Line:        report.Body.LineNumber,
+					Column:      report.Body.ColumnNumber,
+					Disposition: report.Body.Disposition,
+					Sample:      report.Body.Sample,
+				})
+			}
+		default:
+			app.apiError(w, http.StatusUnsupportedMediaType, "Expected an application/csp-report or application/reports+json body.")
+			return
+		}
+
+		for _, v := range violations {
+			app.log(r).Warn(
+				"csp violation",
+				slog.String("document_url", v.DocumentURL),
+				slog.String("blocked_url", v.BlockedURL),
+				slog.String("directive", v.Directive),
+				slog.String("source_file", v.SourceFile),
+				slog.Int("line", v.Line),
+				slog.Int("column", v.Column),
+				slog.String("disposition", v.Disposition),
+				slog.String("sample", v.Sample),
+				slog.String("user_agent", r.UserAgent()),
+			)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
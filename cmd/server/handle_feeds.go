@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ffss.dev/internal/blog"
+	"ffss.dev/internal/feed"
+	"github.com/go-chi/chi/v5"
+)
+
+const feedSize = 20
+
+func (app *application) handleAuthorFeed() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handle := chi.URLParam(r, "handle")
+		author, err := app.blog.GetAuthor(r.Context(), handle)
+		if err != nil {
+			switch {
+			case errors.Is(err, blog.ErrAuthorNotFound):
+				app.notFound(w, r)
+			default:
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		list, err := app.blog.ListArticles(r.Context(), blog.ListArticlesOptions{
+			Sort:     "date",
+			Author:   author.Handle,
+			PageSize: feedSize,
+		})
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		base := app.baseURL(r)
+		authorURL := base + "/authors/" + author.Handle
+		f := &feed.Feed{
+			ID:       authorURL,
+			Title:    author.Name,
+			Subtitle: author.Bio,
+			Links: []feed.Link{
+				{Rel: "self", Type: "application/atom+xml", Href: authorURL + "/feed.xml"},
+				{Rel: "alternate", Type: "text/html", Href: authorURL},
+			},
+			Authors: []feed.Person{{Name: author.Name, URI: authorURL}},
+		}
+		for _, article := range list.Articles {
+			f.Entries = append(f.Entries, app.feedEntry(r, base, article))
+		}
+		f.Updated = feed.Time(feedUpdated(list.Articles))
+
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		if err := f.Write(w); err != nil {
+			app.log(r).Error("failed to write feed: " + err.Error())
+		}
+	}
+}
+
+func (app *application) feedEntry(r *http.Request, base string, article *blog.Article) feed.Entry {
+	articleURL := base + "/articles/" + article.Slug
+
+	entry := feed.Entry{
+		ID:        articleURL,
+		Title:     article.Title,
+		Published: feed.Time(article.PublishedAt()),
+		Updated:   feed.Time(articleUpdated(article)),
+		Links:     []feed.Link{{Rel: "alternate", Type: "text/html", Href: articleURL}},
+		Content:   &feed.Text{Type: "html", Body: string(article.Content)},
+	}
+	if article.Subtitle != "" {
+		entry.Summary = &feed.Text{Type: "text", Body: article.Subtitle}
+	}
+	for _, author := range app.articleAuthors(r, article) {
+		entry.Authors = append(entry.Authors, feed.Person{
+			Name: author.Name,
+			URI:  base + "/authors/" + author.Handle,
+		})
+	}
+	for _, tag := range article.Tags {
+		entry.Categories = append(entry.Categories, feed.Category{Term: tag})
+	}
+	return entry
+}
+
+// Returns the last update of the article, falling back to its publish time.
+func articleUpdated(article *blog.Article) time.Time {
+	if updated := article.UpdatedAt(); !updated.IsZero() {
+		return updated
+	}
+	return article.PublishedAt()
+}
+
+// Returns the most recent update among articles, or now if there are none.
+func feedUpdated(articles []*blog.Article) time.Time {
+	if len(articles) == 0 {
+		return time.Now()
+	}
+	var updated time.Time
+	for _, article := range articles {
+		if t := articleUpdated(article); t.After(updated) {
+			updated = t
+		}
+	}
+	return updated
+}
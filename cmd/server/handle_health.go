@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const readyTimeout = 2 * time.Second
+
+type readyResponse struct {
+	Status   string            `json:"status"`
+	Checks   map[string]string `json:"checks"`
+	Articles int               `json:"articles"`
+}
+
+type versionResponse struct {
+	buildInfo
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Reports whether r is a health or readiness probe, which are polled too often to be worth
+// logging or tracing.
+func isProbe(r *http.Request) bool {
+	return r.URL.Path == "/healthz" || r.URL.Path == "/readyz"
+}
+
+// Reports that the process is alive.
+func (app *application) handleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte("ok\n"))
+	}
+}
+
+// Reports whether the server can serve pages: the database answers, the articles are loaded
+// and the templates parse. Answers 503 Service Unavailable with the failed checks otherwise.
+// Check errors are only logged, the response tells "ok" or "failed" apart.
+func (app *application) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		checks := map[string]error{
+			"database":  app.db.PingContext(ctx),
+			"articles":  app.blog.Check(),
+			"templates": app.checkTemplates(),
+		}
+
+		res := readyResponse{
+			Status:   "ok",
+			Checks:   make(map[string]string, len(checks)),
+			Articles: app.blog.ArticleCount(),
+		}
+		status := http.StatusOK
+		for name, err := range checks {
+			res.Checks[name] = "ok"
+			if err != nil {
+				app.log(r).Warn(
+					"readiness check failed",
+					slog.String("check", name),
+					slog.String("err", err.Error()),
+				)
+				res.Checks[name] = "failed"
+				res.Status = "unavailable"
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		app.writeJSON(w, r, status, res)
+	}
+}
+
+// Reports the VCS revision the server was built from. Served on the metrics server only.
+func (app *application) handleVersion() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		app.writeJSON(w, r, http.StatusOK, versionResponse{
+			buildInfo: app.build,
+			StartedAt: app.startedAt,
+		})
+	}
+}
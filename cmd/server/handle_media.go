@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"ffss.dev/internal/blog"
+	"github.com/go-chi/chi/v5"
+)
+
+func (app *application) handleAvatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handle := chi.URLParam(r, "handle")
+		size, err := strconv.Atoi(chi.URLParam(r, "size"))
+		if err != nil || !slices.Contains(blog.AvatarSizes, size) {
+			app.notFound(w, r)
+			return
+		}
+
+		avatar, err := app.blog.GetAvatar(r.Context(), handle, size)
+		if err != nil {
+			switch {
+			case errors.Is(err, blog.ErrAvatarNotFound):
+				app.notFound(w, r)
+			default:
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		// Versioned URLs never change contents, see [blog.Author.AvatarURL]. Anything else,
+		// including stale or partial versions, is only cached briefly and revalidated.
+		if v := r.URL.Query().Get("v"); v != "" && v == avatar.Version() {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=300, must-revalidate")
+		}
+		w.Header().Set("Content-Type", "image/png")
+		http.ServeContent(w, r, "", avatar.UpdatedAt, bytes.NewReader(avatar.Data))
+	}
+}
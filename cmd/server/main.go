@@ -3,13 +3,16 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"html/template"
 	"io/fs"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"ffss.dev/internal/blog"
 	"ffss.dev/internal/logging"
@@ -17,16 +20,6 @@ import (
 	"ffss.dev/internal/sqlite"
 )
 
-type config struct {
-	addr        string
-	metricsAddr string
-	dev         bool
-	articles    string
-	static      string
-	views       string
-	dbPath      string
-}
-
 type application struct {
 	cfg     config
 	logger  *slog.Logger
@@ -35,9 +28,14 @@ type application struct {
 	metrics *metrics.Collector
 	views   fs.FS
 	static  fs.FS
+	assets  *assetManifest
+	pages   *pageCache
 
 	mu        sync.Mutex
 	templates map[string]*template.Template
+
+	build     buildInfo
+	startedAt time.Time
 }
 
 func (app *application) isDev() bool {
@@ -51,15 +49,25 @@ func main() {
 }
 
 func run() error {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "preview":
+			return runPreview(os.Args[2:])
+		case "authors":
+			return runAuthors(os.Args[2:])
+		case "config":
+			return runConfig(os.Args[2:])
+		}
+	}
+
 	var cfg config
-	flag.StringVar(&cfg.addr, "addr", ":4000", "Sets the HTTP server listen address.")
-	flag.StringVar(&cfg.metricsAddr, "metrics-addr", ":8080", "Sets the metrics HTTP listen address.")
-	flag.BoolVar(&cfg.dev, "dev", true, "Sets the application in development mode.")
-	flag.StringVar(&cfg.articles, "articles", "articles", "Sets the articles dir.")
-	flag.StringVar(&cfg.static, "static", "web/static", "Sets the static dir.")
-	flag.StringVar(&cfg.views, "views", "web/views", "Sets the views dir.")
-	flag.StringVar(&cfg.dbPath, "db-path", "blog.db", "Sets the sqlite database path.")
-	flag.Parse()
+	flags := cfg.flagSet("server", flag.ExitOnError)
+	if _, err := loadConfig(flags, os.Args[1:]); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
 
 	var (
 		static   = os.DirFS(cfg.static)
@@ -69,20 +77,74 @@ func run() error {
 
 	logger := logging.NewLogger(cfg.dev)
 	slog.SetDefault(logger)
+	if !cfg.dev && cfg.site.URL == "" {
+		logger.Warn("site-url is not set, absolute links use the request host")
+	}
 
-	db, err := sqlite.Connect(context.Background(), cfg.dbPath)
+	shutdownTracing, err := setupTracing(context.Background(), cfg.tracing, cfg.otlpEndpoint, cfg.traceSampleRatio)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("failed to flush traces", slog.String("err", err.Error()))
+		}
+	}()
+
+	build := readBuildInfo()
+	col := metrics.NewCollector(logger)
+	col.SetBuildInfo(build.Revision, build.Time, build.Modified, build.GoVersion)
+	if cfg.metricsAddr != "" {
+		go col.ServeMetrics(cfg.metricsAddr)
+	}
+
+	db, err := sqlite.Connect(context.Background(), cfg.dbPath, sqlite.WithMetrics(col))
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	blog, err := blog.New(cfg.dev, db, articles)
+	err = syncAuthors(logger, db, cfg.authorsPath)
 	if err != nil {
 		return err
 	}
 
-	col := metrics.NewCollector(logger)
-	go col.ServeMetrics(cfg.metricsAddr)
+	blog, err := blog.New(
+		cfg.dev,
+		db,
+		articles,
+		blog.WithLogger(logger),
+		blog.WithMetrics(col),
+		blog.WithSkipInvalid(cfg.skipInvalid),
+		blog.WithPreviewSecret([]byte(cfg.previewSecret)),
+	)
+	if err != nil {
+		return err
+	}
+
+	go blog.RunScheduler(context.Background())
+	go func() {
+		client := &http.Client{Timeout: 30 * time.Second}
+		if err := blog.SyncAvatars(context.Background(), client); err != nil {
+			logger.Error("failed to sync avatars", slog.String("err", err.Error()))
+		}
+	}()
+
+	assets, err := newAssetManifest(static)
+	if err != nil {
+		return err
+	}
+	if !cfg.dev {
+		// Not fatal, the file server still compresses on the fly.
+		if err := assets.precompress(cfg.static); err != nil {
+			logger.Warn("failed to precompress static files", slog.String("err", err.Error()))
+		}
+	}
+
+	var pages *pageCache
+	if !cfg.dev && cfg.pageCacheMB > 0 {
+		pages = newPageCache(cfg.pageCacheMB << 20)
+	}
 
 	app := &application{
 		cfg:     cfg,
@@ -91,7 +153,41 @@ func run() error {
 		blog:    blog,
 		metrics: col,
 		static:  static,
+		assets:  assets,
+		pages:   pages,
 		views:   views,
+
+		build:     build,
+		startedAt: time.Now(),
+	}
+	// The build details are internal, so they are only served next to the metrics.
+	col.Handle("GET /version", app.handleVersion())
+	if !cfg.dev {
+		// Fails fast on broken templates rather than on the first request.
+		if err := app.parseTemplates(); err != nil {
+			return err
+		}
 	}
 	return app.serve()
 }
+
+// Syncs the authors file into the database. A missing file is not an error, so databases
+// seeded by migrations keep working.
+func syncAuthors(logger *slog.Logger, db *sql.DB, path string) error {
+	authors, err := blog.LoadAuthors(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("authors file not found, skipping sync", slog.String("path", path))
+			return nil
+		}
+		return err
+	}
+
+	err = blog.SyncAuthors(context.Background(), db, authors)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("synced authors", slog.String("path", path), slog.Int("count", len(authors)))
+	return nil
+}
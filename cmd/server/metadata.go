@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/url"
+	"time"
+
+	"ffss.dev/internal/blog"
+)
+
+const schemaContext = "https://schema.org"
+
+// Per page metadata, rendered by base.tmpl as canonical link, OpenGraph tags and JSON-LD
+// structured data. URLs are absolute.
+type pageMeta struct {
+	Canonical string
+	// OpenGraph type: "website", "article" or "profile".
+	Type  string
+	Image string
+
+	// Article only, formatted as RFC 3339.
+	PublishedTime string
+	ModifiedTime  string
+	// Article author profile URLs.
+	Authors []string
+	Tags    []string
+
+	// Structured data objects, each rendered in its own application/ld+json script. Marshaled
+	// to JSON and escaped by html/template.
+	JSONLD []any
+}
+
+type ldPerson struct {
+	Context     string   `json:"@context,omitempty"`
+	Type        string   `json:"@type"`
+	Name        string   `json:"name"`
+	URL         string   `json:"url,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Description string   `json:"description,omitempty"`
+	SameAs      []string `json:"sameAs,omitempty"`
+}
+
+type ldBlogPosting struct {
+	Context          string     `json:"@context"`
+	Type             string     `json:"@type"`
+	Headline         string     `json:"headline"`
+	Description      string     `json:"description,omitempty"`
+	URL              string     `json:"url"`
+	MainEntityOfPage string     `json:"mainEntityOfPage"`
+	Image            string     `json:"image,omitempty"`
+	DatePublished    string     `json:"datePublished"`
+	DateModified     string     `json:"dateModified"`
+	Author           []ldPerson `json:"author"`
+	Keywords         []string   `json:"keywords,omitempty"`
+	InLanguage       string     `json:"inLanguage,omitempty"`
+}
+
+type ldBreadcrumbList struct {
+	Context         string       `json:"@context"`
+	Type            string       `json:"@type"`
+	ItemListElement []ldListItem `json:"itemListElement"`
+}
+
+type ldListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	// Omitted for the last crumb, the current page.
+	Item string `json:"item,omitempty"`
+}
+
+// Resolves ref, an absolute URL or a path on the site, against the site URL base.
+func absoluteURL(base, ref string) string {
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		return ref
+	}
+	return base + ref
+}
+
+// Returns the absolute image URL for ref, a static asset name or an absolute URL.
+func (app *application) siteImageURL(base, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if u, err := url.Parse(ref); err == nil && u.IsAbs() {
+		return ref
+	}
+	return base + app.assetURL(ref)
+}
+
+// Sets the metadata of an article page: OpenGraph article tags, a BlogPosting and the
+// breadcrumbs leading to it.
+func articleMeta(base *basePage, article *blog.Article, authors []*blog.Author) {
+	site := base.Site
+	canonical := site.URL + "/articles/" + article.Slug
+	published := article.PublishedAt().Format(time.RFC3339)
+	modified := articleUpdated(article).Format(time.RFC3339)
+
+	meta := &base.Meta
+	meta.Canonical = canonical
+	meta.Type = "article"
+	if article.Image != "" {
+		meta.Image = absoluteURL(site.URL, article.Image)
+	}
+	meta.PublishedTime = published
+	meta.ModifiedTime = modified
+	meta.Tags = article.Tags
+
+	posting := ldBlogPosting{
+		Context:          schemaContext,
+		Type:             "BlogPosting",
+		Headline:         article.Title,
+		Description:      article.Subtitle,
+		URL:              canonical,
+		MainEntityOfPage: canonical,
+		Image:            meta.Image,
+		DatePublished:    published,
+		DateModified:     modified,
+		Keywords:         article.Tags,
+		InLanguage:       site.Language,
+	}
+	for _, author := range authors {
+		person := ldPerson{Type: "Person", Name: author.Name}
+		// Placeholders of authors that failed to load have no page to link to.
+		if author.ID != 0 {
+			person.URL = site.URL + "/authors/" + author.Handle
+			meta.Authors = append(meta.Authors, person.URL)
+		}
+		if person.Name == "" {
+			person.Name = author.Handle
+		}
+		posting.Author = append(posting.Author, person)
+	}
+
+	meta.JSONLD = append(meta.JSONLD, posting, ldBreadcrumbList{
+		Context: schemaContext,
+		Type:    "BreadcrumbList",
+		ItemListElement: []ldListItem{
+			{Type: "ListItem", Position: 1, Name: "Articles", Item: site.URL + "/articles"},
+			{Type: "ListItem", Position: 2, Name: article.Title},
+		},
+	})
+}
+
+// Sets the metadata of an author page: the author as a Person with their profiles.
+func authorMeta(base *basePage, author *blog.Author) {
+	site := base.Site
+	canonical := site.URL + "/authors/" + author.Handle
+
+	meta := &base.Meta
+	meta.Canonical = canonical
+	meta.Type = "profile"
+
+	person := ldPerson{
+		Context:     schemaContext,
+		Type:        "Person",
+		Name:        author.Name,
+		URL:         canonical,
+		Description: author.Bio,
+	}
+	if avatar := author.AvatarURL(160); avatar != "" {
+		person.Image = absoluteURL(site.URL, avatar)
+		meta.Image = person.Image
+	}
+	if author.GithubURL != "" {
+		person.SameAs = append(person.SameAs, author.GithubURL)
+	}
+	for _, link := range author.Links {
+		person.SameAs = append(person.SameAs, link.URL)
+	}
+	meta.JSONLD = append(meta.JSONLD, person)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"time"
+
+	"ffss.dev/internal/logging"
+	"github.com/go-chi/chi/v5"
 )
 
 func (app *application) recoverer(next http.Handler) http.Handler {
@@ -19,18 +25,79 @@ func (app *application) recoverer(next http.Handler) http.Handler {
 	})
 }
 
-func (app *application) realIP(next http.Handler) http.Handler {
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// Returns the ID of the request, set by [application.requestID].
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// Reports whether id is safe to use as a request ID: non-empty, bounded and made only of
+// letters, digits and '-', '_', '.' or ':'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Assigns each request an ID, reusing a well formed X-Request-ID header if present, and
+// returns it in the X-Request-ID response header. The request context carries a logger
+// annotated with the ID, see [application.log].
+func (app *application) requestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !app.isDev() {
-			r.RemoteAddr = strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0]
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
 		}
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = logging.NewContext(ctx, app.logger.With(slog.String("request_id", id)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Returns the request scoped logger, falling back to the application logger.
+func (app *application) log(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), app.logger)
+}
+
+func (app *application) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = app.clientIP(r)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// Returns the route pattern matched by the request, e.g. "/articles/{slug}", or "unmatched"
+// if no route matched. Only known once the router has handled the request.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
 type logResponseWriter struct {
 	http.ResponseWriter
 	status int
+	size   int64
 }
 
 func (l *logResponseWriter) WriteHeader(status int) {
@@ -38,6 +105,12 @@ func (l *logResponseWriter) WriteHeader(status int) {
 	l.ResponseWriter.WriteHeader(status)
 }
 
+func (l *logResponseWriter) Write(b []byte) (int, error) {
+	n, err := l.ResponseWriter.Write(b)
+	l.size += int64(n)
+	return n, err
+}
+
 func (l *logResponseWriter) Unwrap() http.ResponseWriter {
 	return l.ResponseWriter
 }
@@ -46,18 +119,22 @@ func (app *application) reqLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t := time.Now()
 		lw := &logResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		app.metrics.IncRequestsInFlight()
 
 		defer func() {
 			duration := time.Since(t)
+			app.metrics.DecRequestsInFlight()
 
-			app.metrics.IncHTTPRequest(r.Method, r.URL.Path, lw.status)
-			app.metrics.RequestDuration(r.Method, r.URL.Path, duration)
+			route := routePattern(r)
+			app.metrics.IncHTTPRequest(r.Method, route, lw.status)
+			app.metrics.RequestDuration(r.Method, route, duration)
+			app.metrics.ResponseSize(r.Method, route, lw.size)
 
 			level := slog.LevelInfo
-			if strings.HasPrefix(r.URL.Path, "/static") {
+			if strings.HasPrefix(r.URL.Path, "/static") || isProbe(r) {
 				level = slog.LevelDebug
 			}
-			app.logger.Log(
+			app.log(r).Log(
 				r.Context(),
 				level,
 				"http request",
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Size bounded LRU cache of rendered pages. All entries are dropped when the blog snapshot
+// version changes, see [blog.Service.Version].
+type pageCache struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	version uint64
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cachedPage struct {
+	key     string
+	body    []byte
+	etag    string
+	modTime time.Time
+}
+
+func newPageCache(maxBytes int) *pageCache {
+	return &pageCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *pageCache) get(key string, version uint64) (*cachedPage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkVersion(version)
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cachedPage), true
+}
+
+// Stores page, evicting the least recently used pages until the cache fits its size bound.
+// Pages larger than the bound are not stored.
+func (c *pageCache) put(version uint64, page *cachedPage) {
+	if len(page.body) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkVersion(version)
+	if el, ok := c.entries[page.key]; ok {
+		c.remove(el)
+	}
+	c.entries[page.key] = c.lru.PushFront(page)
+	c.size += len(page.body)
+
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Drops every entry if version differs from the cached one. Must be called with mu held.
+func (c *pageCache) checkVersion(version uint64) {
+	if version == c.version {
+		return
+	}
+	c.version = version
+	c.size = 0
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *pageCache) remove(el *list.Element) {
+	page := c.lru.Remove(el).(*cachedPage)
+	delete(c.entries, page.key)
+	c.size -= len(page.body)
+}
+
+// Builds the page cache key for the template and its inputs. Returns an empty key, disabling
+// the cache, in dev mode or if the cache is disabled.
+func (app *application) pageCacheKey(r *http.Request, templateName string, parts ...string) string {
+	if app.isDev() || app.pages == nil {
+		return ""
+	}
+	base := app.newBasePage(r, "")
+	// The site URL falls back to the request host, which then changes absolute links.
+	key := []string{templateName, base.Site.URL, r.URL.Path}
+	key = append(key, parts...)
+	if base.IsMac {
+		key = append(key, "mac")
+	}
+	return strings.Join(key, "\x00")
+}
+
+// Writes the cached page for key, if there is one, reporting whether it did.
+func (app *application) serveCachedPage(w http.ResponseWriter, r *http.Request, key string) bool {
+	if key == "" {
+		return false
+	}
+
+	page, ok := app.pages.get(key, app.blog.Version())
+	if !ok {
+		app.metrics.IncPageCacheMiss()
+		return false
+	}
+	app.metrics.IncPageCacheHit()
+
+	app.writePage(w, r, http.StatusOK, page.body, page.etag, page.modTime)
+	return true
+}
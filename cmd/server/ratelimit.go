@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Clients idle for longer than this are forgotten. Their bucket would be full by then anyway
+// for any sensible limit.
+const rateLimitIdle = 10 * time.Minute
+
+// A token bucket rate, allowing bursts of up to burst requests. The zero value means no limit.
+type rateLimit struct {
+	limit rate.Limit
+	burst int
+}
+
+// Limits requests to a route group with a token bucket per client IP.
+type rateLimiter struct {
+	group string
+	rateLimit
+
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Parses a rate limit spec of the form <requests>/<duration>, e.g. 30/1m or 5/s. Clients may
+// burst up to <requests> at once, refilling evenly over <duration>. "0" disables the limit,
+// returning the zero rateLimit.
+func parseRateLimit(spec string) (rateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" {
+		return rateLimit{}, nil
+	}
+
+	n, per, ok := strings.Cut(spec, "/")
+	if !ok {
+		return rateLimit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<duration>", spec)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 1 {
+		return rateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", spec)
+	}
+	// Allows a bare unit, e.g. 5/s.
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	interval, err := time.ParseDuration(per)
+	if err != nil || interval <= 0 {
+		return rateLimit{}, fmt.Errorf("invalid rate limit %q: invalid duration", spec)
+	}
+
+	return rateLimit{
+		limit: rate.Every(interval / time.Duration(requests)),
+		burst: requests,
+	}, nil
+}
+
+// Creates a limiter for the route group, or nil if rl is the zero rateLimit.
+func newRateLimiter(group string, rl rateLimit) *rateLimiter {
+	if rl.burst == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		group:     group,
+		rateLimit: rl,
+		clients:   make(map[string]*rateClient),
+	}
+}
+
+// Takes a token from the bucket of key. If it is empty, returns false and how long until a
+// token is available.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > rateLimitIdle {
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) > rateLimitIdle {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+
+	res := client.limiter.ReserveN(now, 1)
+	delay := res.DelayFrom(now)
+	if delay == 0 {
+		return true, 0
+	}
+	res.CancelAt(now)
+	return false, delay
+}
+
+// Rejects requests from clients over the limiter budget with 429 Too Many Requests, written
+// by onLimit. Clients are keyed by the address resolved by [application.realIP]. A nil
+// limiter lets every request through.
+func (app *application) rateLimit(l *rateLimiter, onLimit http.HandlerFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retryAfter := l.allow(r.RemoteAddr, time.Now())
+			if !ok {
+				app.metrics.IncRateLimited(l.group)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+				onLimit(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Rounds a delay up to whole seconds for the Retry-After header. Never returns less than 1, as
+// 0 would tell clients to retry right away.
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    rateLimit
+		wantErr bool
+	}{
+		{spec: "", want: rateLimit{}},
+		{spec: "0", want: rateLimit{}},
+		{spec: "10/s", want: rateLimit{limit: rate.Every(100 * time.Millisecond), burst: 10}},
+		{spec: "30/1m", want: rateLimit{limit: rate.Every(2 * time.Second), burst: 30}},
+		{spec: " 60/m ", want: rateLimit{limit: rate.Every(time.Second), burst: 60}},
+		{spec: "5/90s", want: rateLimit{limit: rate.Every(18 * time.Second), burst: 5}},
+		{spec: "0/m", wantErr: true},
+		{spec: "-1/m", wantErr: true},
+		{spec: "abc", wantErr: true},
+		{spec: "10", wantErr: true},
+		{spec: "/1m", wantErr: true},
+		{spec: "10/", wantErr: true},
+		{spec: "10/0s", wantErr: true},
+		{spec: "10/fortnight", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRateLimit(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseRateLimit(%q) = %+v, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRateLimit(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRateLimit(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	if l := newRateLimiter("test", rateLimit{}); l != nil {
+		t.Errorf("newRateLimiter() with the zero rateLimit = %+v, want nil", l)
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl, err := parseRateLimit("3/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newRateLimiter("test", rl)
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := range 3 {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("allow() denied request %d within the burst", i+1)
+		}
+	}
+	ok, delay := l.allow("a", now)
+	if ok {
+		t.Fatal("allow() allowed a request over the burst")
+	}
+	// One token refills every 20s.
+	if delay != 20*time.Second {
+		t.Errorf("allow() delay = %v, want 20s", delay)
+	}
+
+	// A denied request does not take a token, so the wait does not grow.
+	if _, delay := l.allow("a", now.Add(5*time.Second)); delay != 15*time.Second {
+		t.Errorf("allow() delay = %v, want 15s", delay)
+	}
+	if ok, _ := l.allow("a", now.Add(20*time.Second)); !ok {
+		t.Error("allow() denied a request after a token refilled")
+	}
+
+	// Clients have their own buckets.
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("allow() denied another client")
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  int
+	}{
+		{delay: time.Nanosecond, want: 1},
+		{delay: 200 * time.Millisecond, want: 1},
+		{delay: time.Second, want: 1},
+		{delay: 1001 * time.Millisecond, want: 2},
+		{delay: 20 * time.Second, want: 20},
+	}
+	for _, tt := range tests {
+		if got := retryAfterSeconds(tt.delay); got != tt.want {
+			t.Errorf("retryAfterSeconds(%v) = %d, want %d", tt.delay, got, tt.want)
+		}
+	}
+
+	// A fast limit denies with a sub-second delay, still reported as 1s.
+	l := newRateLimiter("test", rateLimit{limit: rate.Every(100 * time.Millisecond), burst: 1})
+	now := time.Unix(1_700_000_000, 0)
+	l.allow("a", now)
+	ok, delay := l.allow("a", now)
+	if ok || delay <= 0 || delay >= time.Second {
+		t.Fatalf("allow() = %v, %v, want a sub-second denial", ok, delay)
+	}
+	if got := retryAfterSeconds(delay); got != 1 {
+		t.Errorf("retryAfterSeconds(%v) = %d, want 1", delay, got)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := newRateLimiter("test", rateLimit{limit: rate.Every(time.Second), burst: 1})
+	now := time.Unix(1_700_000_000, 0)
+
+	l.allow("idle", now)
+	l.allow("active", now.Add(rateLimitIdle))
+	// Sweeps run at most once per idle period, on the next request.
+	l.allow("active", now.Add(rateLimitIdle+time.Second))
+	if _, ok := l.clients["idle"]; ok {
+		t.Error("idle client was not swept")
+	}
+	if _, ok := l.clients["active"]; !ok {
+		t.Error("active client was swept")
+	}
+}
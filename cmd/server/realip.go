@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Default trusted proxies: loopback only. Proxies on other hosts, e.g. in a container network,
+// must be listed explicitly, as trusting a network lets any host on it spoof client addresses.
+const defaultTrustedProxies = "127.0.0.0/8,::1/128"
+
+// Parses a comma separated list of CIDRs. Bare addresses are accepted as single host
+// prefixes.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", part, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Reports whether addr belongs to one of the trusted proxy networks.
+func (app *application) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range app.cfg.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolves the client address of the request. Forwarding headers are only honored when the
+// connection comes from a trusted proxy, in order of preference: Forwarded, X-Forwarded-For
+// and X-Real-IP. Forwarded and X-Forwarded-For hops are walked right to left, stopping at the
+// first one that is not a trusted proxy. Falls back to the socket address.
+func (app *application) clientIP(r *http.Request) string {
+	remote, ok := parseHost(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !app.isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		return app.firstUntrusted(forwardedFor(forwarded), remote).String()
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		var hops []string
+		for _, v := range xff {
+			hops = append(hops, strings.Split(v, ",")...)
+		}
+		return app.firstUntrusted(hops, remote).String()
+	}
+	if addr, ok := parseHost(r.Header.Get("X-Real-IP")); ok {
+		return addr.String()
+	}
+	return remote.String()
+}
+
+// Walks hops right to left, returning the first one that is not a trusted proxy. Stops at
+// malformed hops, returning the trusted hop that reported it.
+func (app *application) firstUntrusted(hops []string, remote netip.Addr) netip.Addr {
+	addr := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHost(hops[i])
+		if !ok {
+			break
+		}
+		addr = hop
+		if !app.isTrustedProxy(hop) {
+			break
+		}
+	}
+	return addr
+}
+
+// Returns the for= values of RFC 7239 Forwarded headers, in order.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			hop := ""
+			for _, pair := range strings.Split(element, ";") {
+				k, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(k, "for") {
+					hop = v
+				}
+			}
+			// Elements without for= are kept, so they stop the walk as malformed hops.
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// Parses an address with an optional port, as found in RemoteAddr and forwarding headers,
+// e.g. 192.0.2.1, 192.0.2.1:4711, 2001:db8::1, [2001:db8::1]:4711 or "[2001:db8::1]".
+// Obfuscated identifiers and "unknown" are rejected.
+func parseHost(s string) (netip.Addr, bool) {
+	s = strings.Trim(strings.TrimSpace(s), `"`)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
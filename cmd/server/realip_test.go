@@ -0,0 +1,238 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: nil},
+		{
+			name: "cidrs",
+			in:   "10.0.0.0/8, 2001:db8::/32",
+			want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")},
+		},
+		{name: "masks host bits", in: "192.168.1.7/24", want: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}},
+		{name: "bare ipv4", in: "203.0.113.5", want: []netip.Prefix{netip.MustParsePrefix("203.0.113.5/32")}},
+		{name: "bare ipv6", in: "2001:db8::1", want: []netip.Prefix{netip.MustParsePrefix("2001:db8::1/128")}},
+		{name: "invalid address", in: "not-an-ip", wantErr: true},
+		{name: "invalid cidr", in: "10.0.0.0/99", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTrustedProxies(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseTrustedProxies(%q) = %v, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTrustedProxies(%q) error: %v", tt.in, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseTrustedProxies(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseTrustedProxies(%q)[%d] = %v, want %v", tt.in, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{in: "192.0.2.1", want: "192.0.2.1", wantOK: true},
+		{in: "192.0.2.1:4711", want: "192.0.2.1", wantOK: true},
+		{in: "2001:db8::1", want: "2001:db8::1", wantOK: true},
+		{in: "[2001:db8::1]", want: "2001:db8::1", wantOK: true},
+		{in: "[2001:db8::1]:4711", want: "2001:db8::1", wantOK: true},
+		{in: `"[2001:db8::1]:4711"`, want: "2001:db8::1", wantOK: true},
+		{in: " 192.0.2.1 ", want: "192.0.2.1", wantOK: true},
+		{in: "::ffff:192.0.2.1", want: "192.0.2.1", wantOK: true},
+		{in: "", wantOK: false},
+		{in: "unknown", wantOK: false},
+		{in: "_hidden", wantOK: false},
+		{in: "garbage:80", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseHost(tt.in)
+		if ok != tt.wantOK {
+			t.Errorf("parseHost(%q) ok = %v, want %v", tt.in, ok, tt.wantOK)
+			continue
+		}
+		if ok && got.String() != tt.want {
+			t.Errorf("parseHost(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	got := forwardedFor([]string{
+		`for=192.0.2.60;proto=http;by=203.0.113.43`,
+		`For="[2001:db8:cafe::17]:4711", proto=https`,
+	})
+	want := []string{"192.0.2.60", `"[2001:db8:cafe::17]:4711"`, ""}
+	if len(got) != len(want) {
+		t.Fatalf("forwardedFor() = %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("forwardedFor()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies("127.0.0.0/8,::1/128,10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{cfg: config{trustedProxies: trusted}}
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:   "no headers",
+			remote: "198.51.100.7:51234",
+			want:   "198.51.100.7",
+		},
+		{
+			name:   "bracketed ipv6 socket address",
+			remote: "[2001:db8::7]:51234",
+			want:   "2001:db8::7",
+		},
+		{
+			name:    "untrusted peer headers ignored",
+			remote:  "198.51.100.7:51234",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}, "X-Real-Ip": {"203.0.113.9"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name:    "xff single hop",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "xff stops at first untrusted hop",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9, 198.51.100.2, 10.0.0.3"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "xff spoofed leftmost entry ignored",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4", "198.51.100.2"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "xff all trusted",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"10.0.0.5, 10.0.0.3"}},
+			want:    "10.0.0.5",
+		},
+		{
+			name:    "xff with ports",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9:4711, [2001:db8::9]:4711"}},
+			want:    "2001:db8::9",
+		},
+		{
+			name:    "xff garbage falls back to socket address",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"garbage"}},
+			want:    "127.0.0.1",
+		},
+		{
+			name:    "xff garbage stops the walk",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9, garbage, 10.0.0.3"}},
+			want:    "10.0.0.3",
+		},
+		{
+			name:    "forwarded",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"Forwarded": {"for=192.0.2.60;proto=http;by=203.0.113.43"}},
+			want:    "192.0.2.60",
+		},
+		{
+			name:    "forwarded quoted ipv6 with port",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"Forwarded": {`for="[2001:db8:cafe::17]:4711"`}},
+			want:    "2001:db8:cafe::17",
+		},
+		{
+			name:    "forwarded stops at first untrusted hop",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"Forwarded": {"for=1.2.3.4, for=198.51.100.2, for=10.0.0.3"}},
+			want:    "198.51.100.2",
+		},
+		{
+			name:    "forwarded preferred over xff",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"Forwarded": {"for=192.0.2.60"}, "X-Forwarded-For": {"203.0.113.9"}},
+			want:    "192.0.2.60",
+		},
+		{
+			name:    "forwarded obfuscated falls back to socket address",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"Forwarded": {"for=_hidden"}},
+			want:    "127.0.0.1",
+		},
+		{
+			name:    "x-real-ip",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Real-Ip": {"203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name:    "x-real-ip with port",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Real-Ip": {"[2001:db8::9]:4711"}},
+			want:    "2001:db8::9",
+		},
+		{
+			name:    "x-real-ip garbage falls back to socket address",
+			remote:  "127.0.0.1:8080",
+			headers: map[string][]string{"X-Real-Ip": {"garbage"}},
+			want:    "127.0.0.1",
+		},
+		{
+			name:   "unparsable socket address kept as is",
+			remote: "pipe",
+			want:   "pipe",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, values := range tt.headers {
+				for _, v := range values {
+					r.Header.Add(k, v)
+				}
+			}
+			if got := app.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,36 +6,115 @@ import (
 	"html/template"
 	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/mileusna/useragent"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// Site wide settings, exposed to templates as basePage.Site.
+type site struct {
+	// Public URL of the site, without a trailing slash. Always set in basePage.Site.
+	URL         string
+	Title       string
+	Description string
+	// Default author name, optional.
+	Author   string
+	Language string
+	// Default shared image, a static asset name or an absolute URL. Resolved to an absolute
+	// URL in basePage.Site.
+	Image string
+	// Optional social profiles.
+	Twitter  string
+	Mastodon string
+}
+
 type basePage struct {
+	Site      site
+	Meta      pageMeta
 	Dev       bool
 	IsMac     bool
 	HTMLTitle string
 	UserAgent useragent.UserAgent
+	NoIndex   bool
+	// Content-Security-Policy nonce, required by inline scripts.
+	Nonce string
+
+	// Last time the page contents changed, used for the Last-Modified header. See [application.render].
+	modTime time.Time
+}
+
+func (p basePage) lastModified() time.Time {
+	return p.modTime
 }
 
 func (app *application) newBasePage(r *http.Request, title string) basePage {
 	ua := useragent.Parse(r.UserAgent())
+	site := app.cfg.site
+	site.URL = app.baseURL(r)
+	site.Image = app.siteImageURL(site.URL, site.Image)
 
 	return basePage{
+		Site:      site,
+		Meta:      pageMeta{Type: "website", Image: site.Image},
 		Dev:       app.isDev(),
 		HTMLTitle: title,
 		IsMac:     ua.IsMacOS() || ua.IsIOS(),
 		UserAgent: useragent.Parse(r.UserAgent()),
+		Nonce:     requestNonce(r),
 	}
 }
 
-func (app *application) render(w http.ResponseWriter, _ *http.Request, templateName string, data any) {
+// Renders the template to a buffer and writes it with status. See [application.writePage].
+func (app *application) render(w http.ResponseWriter, r *http.Request, status int, templateName string, data any) {
+	app.renderCached(w, r, status, "", templateName, data)
+}
+
+// Like [application.render], also storing successful responses in the page cache under key,
+// if it is not empty. See [application.pageCacheKey].
+func (app *application) renderCached(w http.ResponseWriter, r *http.Request, status int, key, templateName string, data any) {
+	version := app.blog.Version()
+
+	_, span := tracer.Start(r.Context(), "template "+templateName)
 	buf, err := app.executeTemplate(templateName, data)
 	if err != nil {
-		app.logger.Error(err.Error())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	if err != nil {
+		app.log(r).Error(err.Error())
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
-	_, _ = buf.WriteTo(w)
+
+	var modTime time.Time
+	if p, ok := data.(interface{ lastModified() time.Time }); ok && !p.lastModified().IsZero() {
+		// Template changes are only deployed on restarts, so pages are never older than it.
+		modTime = latest(p.lastModified(), app.startedAt)
+	}
+
+	body := buf.Bytes()
+	tag := etag(body)
+	// Pages embedding the nonce are only valid for this response.
+	nonce := requestNonce(r)
+	if status == http.StatusOK && key != "" && (nonce == "" || !bytes.Contains(body, []byte(nonce))) {
+		app.pages.put(version, &cachedPage{key: key, body: body, etag: tag, modTime: modTime})
+	}
+
+	app.writePage(w, r, status, body, tag, modTime)
+}
+
+// Writes a rendered page. Successful responses get a strong ETag and, if modTime is set, a
+// Last-Modified header, answering conditional requests with 304 Not Modified.
+func (app *application) writePage(w http.ResponseWriter, r *http.Request, status int, body []byte, etag string, modTime time.Time) {
+	if status == http.StatusOK && app.notModified(w, r, etag, modTime) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
 }
 
 func (app *application) executeTemplate(name string, data any) (*bytes.Buffer, error) {
@@ -70,6 +149,18 @@ func (app *application) findTemplate(name string) (*template.Template, error) {
 	return tmpl, nil
 }
 
+// Reports whether the templates parse. In dev mode, they are parsed again on every call.
+func (app *application) checkTemplates() error {
+	_, err := app.findTemplate("error")
+	return err
+}
+
+func (app *application) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"asset": app.assetURL,
+	}
+}
+
 func (app *application) parseTemplates() error {
 	viewSet := [][]string{
 		{"error"},
@@ -80,7 +171,7 @@ func (app *application) parseTemplates() error {
 
 	app.templates = make(map[string]*template.Template)
 	for _, set := range viewSet {
-		tmpl := template.New("base.tmpl").Option("missingkey=zero")
+		tmpl := template.New("base.tmpl").Funcs(app.templateFuncs()).Option("missingkey=zero")
 		tmpl, err := tmpl.ParseFS(app.views, "*.tmpl")
 		if err != nil {
 			return err
@@ -10,23 +10,55 @@ import (
 func (app *application) routes() http.Handler {
 	r := chi.NewMux()
 
+	r.Use(app.traceRoute)
+	r.Use(app.requestID)
 	r.Use(app.realIP)
+	r.Use(app.secureHeaders)
 	r.Use(app.reqLogger)
+	r.Use(app.compress)
 	r.Use(app.recoverer)
 
 	r.NotFound(app.notFound)
 
-	filesrv := fileserver.New(app.static, fileserver.WithCacheControlFunc(cacheControl))
-	r.Mount("/static/", http.StripPrefix("/static/", filesrv))
+	// Probes are not rate limited, load balancers poll them.
+	r.Get("/healthz", app.handleHealthz())
+	r.Get("/readyz", app.handleReadyz())
+
+	var (
+		searchLimit   = app.rateLimit(newRateLimiter("search", app.cfg.searchLimit), app.apiTooManyRequests)
+		apiLimit      = app.rateLimit(newRateLimiter("api", app.cfg.apiLimit), app.apiTooManyRequests)
+		articlesLimit = app.rateLimit(newRateLimiter("articles", app.cfg.articlesLimit), app.tooManyRequests)
+		// Same rate as the API, but its own budget, so a page flooding reports cannot lock
+		// clients out of the API and the other way around.
+		cspLimit = app.rateLimit(newRateLimiter("csp-report", app.cfg.apiLimit), app.apiTooManyRequests)
+	)
+
+	filesrv := fileserver.New(app.static, fileserver.WithCacheControlFunc(app.cacheControl))
+	r.Mount("/static/", http.StripPrefix("/static/", app.resolveAssets(app.servePrecompressed(filesrv))))
 
 	r.Group(func(r chi.Router) {
 		r.Get("/", app.handleHome())
 		r.Get("/articles", app.handleArticleIndex())
-		r.Get("/articles/{slug}", app.handleArticleShow())
+		r.With(articlesLimit).Get("/articles/{slug}", app.handleArticleShow())
 		r.Get("/authors/{handle}", app.handleAuthorShow())
+		r.Get("/authors/{handle}/feed.xml", app.handleAuthorFeed())
+		r.Get("/media/avatars/{handle}/{size}.png", app.handleAvatar())
 
 		r.Route("/api", func(r chi.Router) {
-			r.Get("/search", app.handleSearch())
+			r.With(searchLimit).Get("/search", app.handleSearch())
+			r.With(cspLimit).Post("/csp-report", app.handleCSPReport())
+
+			r.Route("/v1", func(r chi.Router) {
+				r.Use(app.apiCORS)
+				r.Use(apiLimit)
+				r.NotFound(app.apiNotFound)
+				r.MethodNotAllowed(app.apiMethodNotAllowed)
+
+				r.Get("/articles", app.handleAPIArticleIndex())
+				r.Get("/articles/{slug}", app.handleAPIArticleShow())
+				r.Get("/authors/{handle}", app.handleAPIAuthorShow())
+				r.Get("/tags", app.handleAPITagIndex())
+			})
 		})
 
 		// Static
@@ -39,5 +71,5 @@ func (app *application) routes() http.Handler {
 		}
 	})
 
-	return r
+	return app.traceHandler(r)
 }
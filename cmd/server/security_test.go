@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSPHasReporting(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   bool
+	}{
+		{policy: defaultCSP, want: false},
+		{policy: "default-src 'self'; report-uri https://example.com/csp", want: true},
+		{policy: "default-src 'self';REPORT-TO\tendpoint", want: true},
+		{policy: "default-src 'self' https://report-uri.example.com", want: false},
+		{policy: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := cspHasReporting(tt.policy); got != tt.want {
+			t.Errorf("cspHasReporting(%q) = %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestSecureHeadersReporting(t *testing.T) {
+	tests := []struct {
+		name          string
+		csp           string
+		siteURL       string
+		wantPolicy    string
+		wantEndpoints string
+	}{
+		{
+			name:          "site url",
+			csp:           "default-src 'self'",
+			siteURL:       "https://ffss.dev",
+			wantPolicy:    "default-src 'self'; report-uri https://ffss.dev/api/csp-report; report-to csp",
+			wantEndpoints: `csp="https://ffss.dev/api/csp-report"`,
+		},
+		{
+			name:          "request host",
+			csp:           "default-src 'self'",
+			wantPolicy:    "default-src 'self'; report-uri http://example.com/api/csp-report; report-to csp",
+			wantEndpoints: `csp="http://example.com/api/csp-report"`,
+		},
+		{
+			name:       "custom reporting",
+			csp:        "default-src 'self'; report-uri https://reports.example.com",
+			siteURL:    "https://ffss.dev",
+			wantPolicy: "default-src 'self'; report-uri https://reports.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &application{cfg: config{csp: tt.csp, site: site{URL: tt.siteURL}}}
+			h := app.secureHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/", nil))
+
+			if got := w.Header().Get("Content-Security-Policy"); got != tt.wantPolicy {
+				t.Errorf("Content-Security-Policy = %q, want %q", got, tt.wantPolicy)
+			}
+			if got := w.Header().Get("Reporting-Endpoints"); got != tt.wantEndpoints {
+				t.Errorf("Reporting-Endpoints = %q, want %q", got, tt.wantEndpoints)
+			}
+			if strings.Contains(w.Header().Get("Content-Security-Policy"), cspNoncePlaceholder) {
+				t.Error("nonce placeholder was not replaced")
+			}
+		})
+	}
+}
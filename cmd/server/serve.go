@@ -3,16 +3,15 @@ package main
 import (
 	"log/slog"
 	"net/http"
-	"time"
 )
 
 func (app *application) serve() error {
 	srv := &http.Server{
 		Addr:         app.cfg.addr,
 		Handler:      app.routes(),
-		WriteTimeout: 10 * time.Second,
-		ReadTimeout:  5 * time.Second,
-		IdleTimeout:  time.Minute,
+		WriteTimeout: app.cfg.writeTimeout,
+		ReadTimeout:  app.cfg.readTimeout,
+		IdleTimeout:  app.cfg.idleTimeout,
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
 
@@ -4,6 +4,8 @@ import (
 	"io/fs"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // Walks the list of roots and collects all subdirectories.
@@ -26,11 +28,93 @@ func collectDirs(roots ...string) ([]string, error) {
 	return dirs, nil
 }
 
-// Cache files with .ttf extension, 'no-cache' all others in development mode.
-func cacheControl(r *http.Request) string {
-	// Always cache fonts
-	if filepath.Ext(r.URL.Path) == ".ttf" {
-		return "public, max-age=31536000, immutable"
+// Returns the public URL of the site, e.g. https://ffss.dev. Defaults to the scheme and host
+// the request was made to if site-url is not set.
+func (app *application) baseURL(r *http.Request) string {
+	if app.cfg.site.URL != "" {
+		return strings.TrimSuffix(app.cfg.site.URL, "/")
 	}
-	return "no-cache"
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// Picks the offered media type that best matches the request Accept header, honoring quality
+// values. Returns the first offer if the header is missing or nothing matches.
+func negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		q := acceptQuality(accept, offer)
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Returns the quality value the Accept header gives to mediaType, using the most specific
+// matching range. Returns 0 if no range matches.
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		rng := strings.ToLower(strings.TrimSpace(params[0]))
+
+		var s int
+		switch {
+		case rng == mediaType:
+			s = 2
+		case rng == typ+"/*":
+			s = 1
+		case rng == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		q, specificity = qValue(params[1:]), s
+	}
+	return q
+}
+
+// Returns the quality value the Accept-Encoding header gives to coding, falling back to the
+// '*' range. Returns 0 if neither is present.
+func encodingQuality(acceptEncoding, coding string) float64 {
+	q, exact := 0.0, false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		switch {
+		case name == coding:
+			q, exact = qValue(params[1:]), true
+		case name == "*" && !exact:
+			q = qValue(params[1:])
+		}
+	}
+	return q
+}
+
+// Returns the q parameter among params, 1 if it is missing or malformed.
+func qValue(params []string) float64 {
+	for _, param := range params {
+		k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if k == "q" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	}
+	return 1
 }
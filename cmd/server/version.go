@@ -0,0 +1,36 @@
+package main
+
+import (
+	"runtime/debug"
+)
+
+// Version control details embedded by the Go toolchain when building from a checkout.
+type buildInfo struct {
+	Revision  string `json:"revision"`
+	Time      string `json:"time"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+}
+
+// Reads the build info of the running binary. Fields are "unknown" if the binary was built
+// without VCS stamping, e.g. with -buildvcs=false or outside of a git checkout.
+func readBuildInfo() buildInfo {
+	info := buildInfo{Revision: "unknown", Time: "unknown", GoVersion: "unknown"}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = bi.GoVersion
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
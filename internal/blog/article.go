@@ -6,19 +6,32 @@ import (
 	"fmt"
 	"html/template"
 	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	ErrArticleNotFound = errors.New("article not found")
 )
 
+// Article front matter, see [parseFrontMatter] for the validation rules.
 type ArticleMetadata struct {
-	Title    string   `yaml:"title"`
-	Subtitle string   `yaml:"subtitle"`
-	Author   string   `yaml:"author"`
-	Draft    bool     `yaml:"draft"`
-	Date     string   `yaml:"date"`
-	Tags     []string `yaml:"tags"`
+	Title    string
+	Subtitle string
+	// Author handles, without the '@' prefix.
+	Authors []string
+	Draft   bool
+	Date    time.Time
+	Tags    []string
+	// Optional cover image, an absolute URL or a path on the site, e.g. /static/images/cover.png.
+	Image string
+	// Optional publish time. If unset, the article is published at Date.
+	PublishAt time.Time
+	// Optional last update date. Overrides the detected content change time.
+	Updated   time.Time
+	Changelog []ChangelogEntry
 }
 
 type Article struct {
@@ -26,12 +39,18 @@ type Article struct {
 	Content    template.HTML
 	RawContent string
 	PageViews  int
+	// Last time the article content changed after it was first parsed, zero if never.
+	ChangedAt time.Time
+	TOC       []Heading
 
 	ArticleMetadata
 }
 
-func (s *Service) GetArticle(ctx context.Context, slug string) (*Article, error) {
-	article, err := s.getArticle(slug)
+func (s *Service) GetArticle(ctx context.Context, slug string) (_ *Article, err error) {
+	ctx, span := startSpan(ctx, "GetArticle", attribute.String("blog.article.slug", slug))
+	defer func() { endSpan(span, err) }()
+
+	article, err := s.getArticle(ctx, slug, false)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +58,9 @@ func (s *Service) GetArticle(ctx context.Context, slug string) (*Article, error)
 	return article, nil
 }
 
-func (s *Service) getArticle(slug string) (*Article, error) {
+// Looks up an article in the cache. If preview is set, drafts and scheduled articles are
+// returned as well.
+func (s *Service) getArticle(ctx context.Context, slug string, preview bool) (*Article, error) {
 	if err := s.refreshArticles(); err != nil {
 		return nil, err
 	}
@@ -48,51 +69,143 @@ func (s *Service) getArticle(slug string) (*Article, error) {
 	if !ok {
 		return nil, ErrArticleNotFound
 	}
-	if !s.dev && article.Draft {
+	if !preview && !s.isVisible(article, time.Now()) {
 		return nil, ErrArticleNotFound
 	}
 	return article, nil
 }
 
-func (s *Service) ListArticles(ctx context.Context, sort string) ([]*Article, error) {
-	articles, err := s.listArticles(sort)
+type ListArticlesOptions struct {
+	// One of "date" or "popular". Defaults to "date".
+	Sort string
+	// Only returns articles tagged with Tag, if set. Comparison is case-insensitive.
+	Tag string
+	// 1-indexed page number. Values lower than 1 are treated as 1.
+	Page int
+	// Only returns articles written by the author with Handle, if set.
+	Author string
+	// Number of articles per page. If zero or negative, all articles are returned.
+	PageSize int
+}
+
+type ArticleList struct {
+	Articles []*Article
+	Pagination
+}
+
+func (s *Service) ListArticles(ctx context.Context, opts ListArticlesOptions) (_ *ArticleList, err error) {
+	ctx, span := startSpan(ctx, "ListArticles",
+		attribute.String("blog.list.sort", opts.Sort),
+		attribute.String("blog.list.tag", opts.Tag),
+		attribute.String("blog.list.author", opts.Author),
+		attribute.Int("blog.list.page", opts.Page),
+	)
+	defer func() { endSpan(span, err) }()
+
+	list, err := s.listArticles(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return articles, nil
+	return list, nil
 }
 
-func (s *Service) listArticles(sort string) ([]*Article, error) {
+func (s *Service) listArticles(ctx context.Context, opts ListArticlesOptions) (*ArticleList, error) {
 	if err := s.refreshArticles(); err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	articles := make([]*Article, 0)
 	for _, article := range s.cache {
-		if !s.dev && article.Draft {
+		if !s.isVisible(article, now) {
+			continue
+		}
+		if opts.Tag != "" && !article.HasTag(opts.Tag) {
+			continue
+		}
+		if opts.Author != "" && !article.HasAuthor(opts.Author) {
 			continue
 		}
 		articles = append(articles, article)
 	}
 
-	switch sort {
+	switch opts.Sort {
 	case "popular":
 		slices.SortFunc(articles, popularSort)
 	default:
 		slices.SortFunc(articles, dateSort)
 	}
 
-	return articles, nil
+	pagination := Pagination{
+		Page:     max(opts.Page, 1),
+		PageSize: opts.PageSize,
+		Total:    len(articles),
+	}
+	start, end := pagination.bounds()
+
+	list := &ArticleList{
+		Articles:   articles[start:end],
+		Pagination: pagination,
+	}
+	return list, nil
 }
 
-func (s *Service) SavePageview(ctx context.Context, slug, ipAddress, userAgent, referrer string) error {
+// Returns the time the article becomes public: PublishAt if set, Date otherwise.
+func (a *Article) PublishedAt() time.Time {
+	if !a.PublishAt.IsZero() {
+		return a.PublishAt
+	}
+	return a.Date
+}
+
+// Returns when the article was last updated: the updated front matter field if set, the
+// detected content change time otherwise. Returns the zero time if it was never updated.
+func (a *Article) UpdatedAt() time.Time {
+	if !a.Updated.IsZero() {
+		return a.Updated
+	}
+	if a.ChangedAt.After(a.PublishedAt()) {
+		return a.ChangedAt
+	}
+	return time.Time{}
+}
+
+// Reports whether the article is scheduled to be published after now.
+func (a *Article) IsScheduled(now time.Time) bool {
+	return now.Before(a.PublishedAt())
+}
+
+// In dev mode all articles are visible. Otherwise, drafts and scheduled articles are hidden.
+func (s *Service) isVisible(article *Article, now time.Time) bool {
+	if s.dev {
+		return true
+	}
+	return !article.Draft && !article.IsScheduled(now)
+}
+
+// Reports whether handle is one of the article authors. The '@' prefix is optional.
+func (a *Article) HasAuthor(handle string) bool {
+	return slices.Contains(a.Authors, strings.TrimPrefix(handle, "@"))
+}
+
+// Reports whether the article is tagged with tag, ignoring case.
+func (a *Article) HasTag(tag string) bool {
+	return slices.ContainsFunc(a.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+func (s *Service) SavePageview(ctx context.Context, slug, ipAddress, userAgent, referrer string) (err error) {
+	ctx, span := startSpan(ctx, "SavePageview", attribute.String("blog.article.slug", slug))
+	defer func() { endSpan(span, err) }()
+
 	query := `
 	INSERT INTO pageviews (slug, ip_address, user_agent, referrer)
 	VALUES ($1, $2, $3, $4)`
 	args := []any{slug, ipAddress, userAgent, referrer}
 
-	_, err := s.db.ExecContext(ctx, query, args...)
+	_, err = s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("blog: failed to save pageview: %w", err)
 	}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -13,18 +16,46 @@ var (
 )
 
 type Author struct {
-	ID        int64  `json:"id"`
-	Handle    string `json:"handle"`
-	Name      string `json:"name"`
-	Bio       string `json:"bio"`
-	Birth     string `json:"birth"`
-	ImageURL  string `json:"image_url"`
-	GithubURL string `json:"github_url"`
+	ID        int64  `json:"id" yaml:"-"`
+	Handle    string `json:"handle" yaml:"handle"`
+	Name      string `json:"name" yaml:"name"`
+	Bio       string `json:"bio" yaml:"bio,omitempty"`
+	Birth     string `json:"birth" yaml:"birth"`
+	ImageURL  string `json:"image_url" yaml:"image_url,omitempty"`
+	GithubURL string `json:"github_url" yaml:"github_url,omitempty"`
+	Pronouns  string `json:"pronouns" yaml:"pronouns,omitempty"`
+	Location  string `json:"location" yaml:"location,omitempty"`
+
+	Links []AuthorLink `json:"links" yaml:"links,omitempty"`
+
+	// Hash of the stored avatar source image, empty if there is none. See [Service.SyncAvatars].
+	AvatarHash string `json:"-" yaml:"-"`
+}
+
+// Supported [AuthorLink] kinds and their display labels.
+var linkKinds = map[string]string{
+	"mastodon": "Mastodon",
+	"bluesky":  "Bluesky",
+	"linkedin": "LinkedIn",
+	"website":  "Website",
+}
+
+// A social profile or website of an author, rendered with rel="me".
+type AuthorLink struct {
+	Kind string `json:"kind" yaml:"kind"`
+	URL  string `json:"url" yaml:"url"`
+}
+
+func (l AuthorLink) Label() string {
+	return linkKinds[l.Kind]
 }
 
 // Grabs an author by it's handle. If handle is prefixed with and '@', it is trimmed automatically. Returns
 // [ErrAuthorNotFound] if no results are returned.
-func (b *Service) GetAuthor(ctx context.Context, handle string) (*Author, error) {
+func (b *Service) GetAuthor(ctx context.Context, handle string) (_ *Author, err error) {
+	ctx, span := startSpan(ctx, "GetAuthor", attribute.String("blog.author.handle", handle))
+	defer func() { endSpan(span, err) }()
+
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -37,19 +68,28 @@ func (b *Service) GetAuthor(ctx context.Context, handle string) (*Author, error)
 			bio, 
 			birth, 
 			image_url, 
-			github_url 
+			github_url,
+			pronouns,
+			location,
+			COALESCE((SELECT hash FROM author_avatars WHERE handle = authors.handle LIMIT 1), '')
 		FROM authors 
 		WHERE handle = $1`
 
-	var author Author
-	err := b.db.QueryRowContext(ctx, query, handle).Scan(
+	var (
+		author Author
+		birth  time.Time
+	)
+	err = b.db.QueryRowContext(ctx, query, handle).Scan(
 		&author.ID,
 		&author.Handle,
 		&author.Name,
 		&author.Bio,
-		&author.Birth,
+		&birth,
 		&author.ImageURL,
 		&author.GithubURL,
+		&author.Pronouns,
+		&author.Location,
+		&author.AvatarHash,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -58,5 +98,54 @@ func (b *Service) GetAuthor(ctx context.Context, handle string) (*Author, error)
 		return nil, err
 	}
 
+	author.Birth = birth.Format(time.DateOnly)
+
+	author.Links, err = b.getAuthorLinks(ctx, author.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &author, nil
 }
+
+func (b *Service) getAuthorLinks(ctx context.Context, authorID int64) ([]AuthorLink, error) {
+	query := `
+		SELECT kind, url
+		FROM author_links
+		WHERE author_id = $1
+		ORDER BY position`
+	rows, err := b.db.QueryContext(ctx, query, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("blog: failed to get author links: %w", err)
+	}
+	defer rows.Close()
+
+	links := make([]AuthorLink, 0)
+	for rows.Next() {
+		var link AuthorLink
+		if err := rows.Scan(&link.Kind, &link.URL); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// Returns the set of all author handles.
+func (b *Service) authorHandles() (map[string]bool, error) {
+	rows, err := b.db.Query("SELECT handle FROM authors")
+	if err != nil {
+		return nil, fmt.Errorf("blog: failed to list authors: %w", err)
+	}
+	defer rows.Close()
+
+	handles := make(map[string]bool)
+	for rows.Next() {
+		var handle string
+		if err := rows.Scan(&handle); err != nil {
+			return nil, err
+		}
+		handles[handle] = true
+	}
+	return handles, rows.Err()
+}
@@ -0,0 +1,209 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrDuplicateAuthor = errors.New("author handle already exists")
+)
+
+var handleRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type authorsFile struct {
+	Authors []*Author `yaml:"authors"`
+}
+
+// Loads and validates the authors from a YAML file at path. See [ValidateAuthor] for the
+// validation rules. Handles must be unique.
+func LoadAuthors(path string) ([]*Author, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file authorsFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i, author := range file.Authors {
+		if err := ValidateAuthor(author); err != nil {
+			errs = append(errs, fmt.Errorf("%s: author #%d: %w", path, i+1, err))
+			continue
+		}
+		if seen[author.Handle] {
+			errs = append(errs, fmt.Errorf("%s: author #%d: %w: %q", path, i+1, ErrDuplicateAuthor, author.Handle))
+		}
+		seen[author.Handle] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return file.Authors, nil
+}
+
+// Writes authors to a YAML file at path, sorted by handle.
+func WriteAuthors(path string, authors []*Author) error {
+	authors = slices.Clone(authors)
+	slices.SortFunc(authors, func(a, b *Author) int {
+		return strings.Compare(a.Handle, b.Handle)
+	})
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(authorsFile{Authors: authors}); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// Validates an author. The handle is normalized by trimming the '@' prefix and must contain only
+// lowercase letters, digits, '-' and '_'. Name is required, birth must be a YYYY-MM-DD date in
+// the past and URLs, when set, must be absolute http(s) URLs. Links must be of a known kind.
+func ValidateAuthor(author *Author) error {
+	author.Handle = strings.TrimPrefix(strings.TrimSpace(author.Handle), "@")
+
+	var errs []error
+	if !handleRegexp.MatchString(author.Handle) {
+		errs = append(errs, fmt.Errorf("invalid handle %q", author.Handle))
+	}
+	if strings.TrimSpace(author.Name) == "" {
+		errs = append(errs, errors.New("name must not be blank"))
+	}
+	birth, err := time.Parse(time.DateOnly, author.Birth)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid birth %q: expected a YYYY-MM-DD date", author.Birth))
+	} else if birth.After(time.Now()) {
+		errs = append(errs, fmt.Errorf("invalid birth %q: must be in the past", author.Birth))
+	}
+	urls := []struct{ field, value string }{
+		{"image_url", author.ImageURL},
+		{"github_url", author.GithubURL},
+	}
+	for _, u := range urls {
+		if u.value == "" {
+			continue
+		}
+		if err := validateURL(u.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", u.field, u.value, err))
+		}
+	}
+	for i, link := range author.Links {
+		if _, ok := linkKinds[link.Kind]; !ok {
+			errs = append(errs, fmt.Errorf("link #%d: unknown kind %q", i+1, link.Kind))
+		}
+		if err := validateURL(link.URL); err != nil {
+			errs = append(errs, fmt.Errorf("link #%d: invalid url %q: %w", i+1, link.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("expected an http or https URL")
+	}
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
+
+// Replaces the contents of the authors table with authors, matching rows by handle. Rows whose
+// handle is not in authors are deleted.
+func SyncAuthors(ctx context.Context, db *sql.DB, authors []*Author) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	handles := make([]any, 0, len(authors))
+	for _, author := range authors {
+		query := `
+		INSERT INTO authors (handle, name, bio, birth, image_url, github_url, pronouns, location)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (handle) DO UPDATE SET
+			name = excluded.name,
+			bio = excluded.bio,
+			birth = excluded.birth,
+			image_url = excluded.image_url,
+			github_url = excluded.github_url,
+			pronouns = excluded.pronouns,
+			location = excluded.location
+		RETURNING id`
+		args := []any{
+			author.Handle,
+			author.Name,
+			author.Bio,
+			author.Birth,
+			author.ImageURL,
+			author.GithubURL,
+			author.Pronouns,
+			author.Location,
+		}
+		var id int64
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("blog: failed to sync author %q: %w", author.Handle, err)
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM author_links WHERE author_id = $1", id)
+		if err != nil {
+			return fmt.Errorf("blog: failed to sync author %q links: %w", author.Handle, err)
+		}
+		for i, link := range author.Links {
+			query := `
+			INSERT INTO author_links (author_id, kind, url, position)
+			VALUES ($1, $2, $3, $4)`
+			_, err := tx.ExecContext(ctx, query, id, link.Kind, link.URL, i)
+			if err != nil {
+				return fmt.Errorf("blog: failed to sync author %q links: %w", author.Handle, err)
+			}
+		}
+
+		handles = append(handles, author.Handle)
+	}
+
+	// Links are removed explicitly, since foreign keys may be disabled.
+	where := ""
+	if len(handles) > 0 {
+		where = " WHERE handle NOT IN (?" + strings.Repeat(", ?", len(handles)-1) + ")"
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM author_links WHERE author_id IN (SELECT id FROM authors"+where+")", handles...)
+	if err != nil {
+		return fmt.Errorf("blog: failed to remove authors: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM authors"+where, handles...)
+	if err != nil {
+		return fmt.Errorf("blog: failed to remove authors: %w", err)
+	}
+
+	return tx.Commit()
+}
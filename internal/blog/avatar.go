@@ -0,0 +1,238 @@
+package blog
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/image/draw"
+)
+
+var (
+	ErrAvatarNotFound = errors.New("avatar not found")
+)
+
+// Square sizes, in pixels, avatars are resized to.
+var AvatarSizes = []int{40, 80, 160}
+
+const (
+	// Max size of a fetched avatar image.
+	maxAvatarBytes = 5 << 20
+	// Max width or height of a fetched avatar image.
+	maxAvatarSide = 4096
+	// Length of the hash prefix versioning avatar URLs.
+	avatarVersionLen = 8
+)
+
+type Avatar struct {
+	Data      []byte
+	Hash      string
+	UpdatedAt time.Time
+}
+
+// Returns the version of the avatar used in its URLs, see [Author.AvatarURL].
+func (a *Avatar) Version() string {
+	return avatarVersion(a.Hash)
+}
+
+func avatarVersion(hash string) string {
+	if len(hash) < avatarVersionLen {
+		return hash
+	}
+	return hash[:avatarVersionLen]
+}
+
+// Returns the self-hosted avatar URL for size, falling back to ImageURL if no avatar was stored.
+func (a *Author) AvatarURL(size int) string {
+	if a.AvatarHash == "" {
+		return a.ImageURL
+	}
+	return fmt.Sprintf("/media/avatars/%s/%d.png?v=%s", a.Handle, size, avatarVersion(a.AvatarHash))
+}
+
+// Returns the srcset attribute value listing every stored avatar size, empty if there is none.
+func (a *Author) AvatarSrcset() string {
+	if a.AvatarHash == "" {
+		return ""
+	}
+	srcset := make([]string, 0, len(AvatarSizes))
+	for _, size := range AvatarSizes {
+		srcset = append(srcset, fmt.Sprintf("%s %dw", a.AvatarURL(size), size))
+	}
+	return strings.Join(srcset, ", ")
+}
+
+// Grabs the stored avatar of the author with handle at size. Returns [ErrAvatarNotFound] if
+// there is none.
+func (s *Service) GetAvatar(ctx context.Context, handle string, size int) (_ *Avatar, err error) {
+	ctx, span := startSpan(ctx, "GetAvatar",
+		attribute.String("blog.author.handle", handle),
+		attribute.Int("blog.avatar.size", size),
+	)
+	defer func() { endSpan(span, err) }()
+
+	query := `
+	SELECT data, hash, updated_at
+	FROM author_avatars
+	WHERE handle = $1 AND size = $2`
+
+	var avatar Avatar
+	err = s.db.QueryRowContext(ctx, query, handle, size).Scan(&avatar.Data, &avatar.Hash, &avatar.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAvatarNotFound
+		}
+		return nil, err
+	}
+
+	return &avatar, nil
+}
+
+// Fetches each author image_url that changed since it was last stored, resizing it to every
+// [AvatarSizes]. Failures are logged and the previous avatar, if any, is kept.
+func (s *Service) SyncAvatars(ctx context.Context, client *http.Client) (err error) {
+	ctx, span := startSpan(ctx, "SyncAvatars")
+	defer func() { endSpan(span, err) }()
+
+	query := `
+	SELECT a.handle, a.image_url
+	FROM authors a
+	WHERE a.image_url != '' AND a.image_url NOT IN (
+		SELECT source_url FROM author_avatars WHERE handle = a.handle
+	)`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("blog: failed to list avatars: %w", err)
+	}
+
+	type pending struct{ handle, url string }
+	var avatars []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.handle, &p.url); err != nil {
+			rows.Close()
+			return err
+		}
+		avatars = append(avatars, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range avatars {
+		err := s.syncAvatar(ctx, client, p.handle, p.url)
+		if err != nil {
+			s.log(ctx).Warn(
+				"failed to sync avatar",
+				slog.String("handle", p.handle),
+				slog.String("url", p.url),
+				slog.String("err", err.Error()),
+			)
+			continue
+		}
+		s.version.Add(1)
+		s.log(ctx).Info("synced avatar", slog.String("handle", p.handle))
+	}
+
+	return nil
+}
+
+func (s *Service) syncAvatar(ctx context.Context, client *http.Client, handle, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	contents, err := io.ReadAll(io.LimitReader(res.Body, maxAvatarBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(contents) > maxAvatarBytes {
+		return fmt.Errorf("image exceeds %d bytes", maxAvatarBytes)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width > maxAvatarSide || cfg.Height > maxAvatarSide {
+		return fmt.Errorf("image exceeds %dx%d pixels", maxAvatarSide, maxAvatarSide)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(contents))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	sum := sha256.Sum256(contents)
+	hash := hex.EncodeToString(sum[:])
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, size := range AvatarSizes {
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, resizeSquare(src, size)); err != nil {
+			return err
+		}
+
+		query := `
+		INSERT INTO author_avatars (handle, size, source_url, hash, data, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (handle, size) DO UPDATE SET
+			source_url = excluded.source_url,
+			hash = excluded.hash,
+			data = excluded.data,
+			updated_at = excluded.updated_at`
+		args := []any{handle, size, url, hash, buf.Bytes(), time.Now().UTC()}
+		_, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("blog: failed to save avatar: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Center crops src to a square and scales it to size x size.
+func resizeSquare(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		b.Min.X+(b.Dx()-side)/2,
+		b.Min.Y+(b.Dy()-side)/2,
+	))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
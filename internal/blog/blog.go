@@ -2,39 +2,96 @@ package blog
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"io/fs"
+	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"ffss.dev/internal/logging"
 	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting/v2"
-	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
-	"gopkg.in/yaml.v2"
+	"github.com/yuin/goldmark/text"
 )
 
 type Service struct {
-	dev      bool
-	db       *sql.DB
-	md       goldmark.Markdown
-	articles fs.FS
+	dev         bool
+	db          *sql.DB
+	md          goldmark.Markdown
+	articles    fs.FS
+	logger      *slog.Logger
+	metrics     Metrics
+	skipInvalid bool
+	// Key used to verify draft preview tokens. Previews are disabled if empty.
+	previewSecret []byte
 
 	mu    sync.Mutex
 	cache map[string]*Article
+	// Error of the last dev mode refresh, nil once a refresh succeeds.
+	refreshErr error
+	// Incremented whenever the visible articles or their rendered data may have changed.
+	version atomic.Uint64
 }
 
-func New(dev bool, db *sql.DB, articles fs.FS) (*Service, error) {
+type Option func(*Service)
+
+// Records service measurements, see [WithMetrics].
+type Metrics interface {
+	// Time taken to parse and index all articles.
+	ArticleRefreshDuration(d time.Duration)
+	SearchDuration(d time.Duration)
+	// Number of articles loaded after a parse.
+	SetArticleCount(n int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ArticleRefreshDuration(time.Duration) {}
+func (nopMetrics) SearchDuration(time.Duration)         {}
+func (nopMetrics) SetArticleCount(int)                  {}
+
+// Sets where the service reports its measurements. Defaults to discarding them.
+func WithMetrics(m Metrics) Option {
+	return func(s *Service) {
+		s.metrics = m
+	}
+}
+
+// Sets the logger used by the service. Defaults to [slog.Default].
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// If skip is true, articles with invalid front matter are skipped and logged as warnings
+// instead of failing the whole parse.
+func WithSkipInvalid(skip bool) Option {
+	return func(s *Service) {
+		s.skipInvalid = skip
+	}
+}
+
+// Sets the secret used to verify preview tokens, see [SignPreview].
+func WithPreviewSecret(secret []byte) Option {
+	return func(s *Service) {
+		s.previewSecret = secret
+	}
+}
+
+func New(dev bool, db *sql.DB, articles fs.FS, opts ...Option) (*Service, error) {
 	md := goldmark.New(
 		goldmark.WithExtensions(
 			extension.GFM,
-			meta.Meta,
 			highlighting.NewHighlighting(
 				highlighting.WithStyle("catppuccin-mocha"),
 				highlighting.WithFormatOptions(
@@ -52,8 +109,14 @@ func New(dev bool, db *sql.DB, articles fs.FS) (*Service, error) {
 		db:       db,
 		md:       md,
 		articles: articles,
+		logger:   slog.Default(),
+		metrics:  nopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(service)
 	}
 
+	t := time.Now()
 	err := service.parseArticles()
 	if err != nil {
 		return nil, err
@@ -63,6 +126,7 @@ func New(dev bool, db *sql.DB, articles fs.FS) (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	service.metrics.ArticleRefreshDuration(time.Since(t))
 
 	return service, nil
 }
@@ -73,62 +137,112 @@ func (s *Service) parseArticles() error {
 		return err
 	}
 
+	authors, err := s.authorHandles()
+	if err != nil {
+		return err
+	}
+
 	cache := make(map[string]*Article)
 	for _, path := range paths {
-		f, err := s.articles.Open(path)
+		article, err := s.parseArticle(path, authors)
 		if err != nil {
+			if s.skipInvalid && isFrontMatterError(err) {
+				s.logger.Warn(
+					"skipping invalid article",
+					slog.String("path", path),
+					slog.String("err", err.Error()),
+				)
+				continue
+			}
 			return err
 		}
-		defer f.Close()
+		cache[article.Slug] = article
+	}
 
-		buf := new(bytes.Buffer)
-		contents, err := io.ReadAll(f)
-		if err != nil {
-			return err
-		}
+	s.cache = cache
+	s.version.Add(1)
+	s.metrics.SetArticleCount(len(cache))
+	return nil
+}
 
-		context := parser.NewContext()
-		err = s.md.Convert(contents, buf, parser.WithContext(context))
-		if err != nil {
-			return err
-		}
+// Returns the number of articles loaded, including drafts and scheduled articles.
+func (s *Service) ArticleCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cache)
+}
 
-		metadata := meta.Get(context)
-		b, err := yaml.Marshal(metadata)
-		if err != nil {
-			return err
-		}
+// Reports whether the service is ready to serve articles. In dev mode, returns the error of
+// the last refresh if it failed.
+func (s *Service) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshErr
+}
 
-		var articleMetadata ArticleMetadata
-		err = yaml.Unmarshal(b, &articleMetadata)
-		if err != nil {
-			return err
-		}
+// Returns the version of the article snapshot. Data derived from articles or authors, like
+// rendered pages, must be discarded when the version changes.
+func (s *Service) Version() uint64 {
+	return s.version.Load()
+}
 
-		slug := strings.TrimSuffix(path, ".md")
+func (s *Service) parseArticle(path string, authors map[string]bool) (*Article, error) {
+	contents, err := fs.ReadFile(s.articles, path)
+	if err != nil {
+		return nil, err
+	}
 
-		var views int
-		err = s.db.QueryRow("SELECT COUNT(*) FROM pageviews WHERE slug = $1", slug).Scan(&views)
-		if err != nil {
-			switch {
-			case errors.Is(err, sql.ErrNoRows):
-				views = 0
-			default:
-				return err
-			}
-		}
+	front, body, err := splitFrontMatter(contents)
+	if err != nil {
+		return nil, &FrontMatterError{Path: path, Line: 1, Err: err}
+	}
+
+	articleMetadata, err := parseFrontMatter(path, front, authors)
+	if err != nil {
+		return nil, err
+	}
+
+	slug := strings.TrimSuffix(path, ".md")
+
+	changedAt, err := s.recordRevision(slug, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := s.md.Parser().Parse(text.NewReader(body))
+	buf := new(bytes.Buffer)
+	err = s.md.Renderer().Render(buf, body, doc)
+	if err != nil {
+		return nil, err
+	}
 
-		cache[slug] = &Article{
-			Slug:            slug,
-			Content:         template.HTML(buf.String()),
-			RawContent:      string(contents),
-			ArticleMetadata: articleMetadata,
-			PageViews:       views,
+	var views int
+	err = s.db.QueryRow("SELECT COUNT(*) FROM pageviews WHERE slug = $1", slug).Scan(&views)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			views = 0
+		default:
+			return nil, err
 		}
 	}
 
-	s.cache = cache
-	return nil
+	article := &Article{
+		Slug:            slug,
+		Content:         template.HTML(buf.String()),
+		RawContent:      string(contents),
+		ArticleMetadata: articleMetadata,
+		PageViews:       views,
+		ChangedAt:       changedAt,
+		TOC:             tableOfContents(doc, body),
+	}
+	return article, nil
+}
+
+// Reports whether err is, or joins, a [*FrontMatterError].
+func isFrontMatterError(err error) bool {
+	var fmErr *FrontMatterError
+	return errors.As(err, &fmErr)
 }
 
 // If dev mode is on, parses all articles and set them to the cache.
@@ -140,14 +254,25 @@ func (s *Service) refreshArticles() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	t := time.Now()
 	err := s.parseArticles()
 	if err != nil {
-		return fmt.Errorf("failed to refresh articles from fs: %w", err)
+		s.refreshErr = fmt.Errorf("blog: failed to refresh articles from fs: %w", err)
+		return s.refreshErr
 	}
 
 	err = s.indexContents()
 	if err != nil {
-		return err
+		s.refreshErr = fmt.Errorf("blog: failed to index articles: %w", err)
+		return s.refreshErr
 	}
+	s.refreshErr = nil
+	s.metrics.ArticleRefreshDuration(time.Since(t))
 	return nil
 }
+
+// Returns the logger carried by ctx, falling back to the service logger, so logs can be
+// correlated with the request that caused them.
+func (s *Service) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
@@ -0,0 +1,235 @@
+package blog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrMissingFrontMatter = errors.New("missing front matter")
+)
+
+// Accepted layouts for the date front matter field.
+var dateLayouts = []string{
+	time.DateOnly,
+	time.RFC3339,
+}
+
+var frontMatterDelim = []byte("---")
+
+// Describes an invalid front matter field, pointing to the file and line where it was found.
+type FrontMatterError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *FrontMatterError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Err)
+}
+
+func (e *FrontMatterError) Unwrap() error {
+	return e.Err
+}
+
+// Splits the YAML front matter from the markdown body. The front matter must start at the first
+// line of the file and be delimited by '---' lines. The returned front matter keeps a leading
+// blank line in place of the opening delimiter so YAML line numbers match the file.
+func splitFrontMatter(contents []byte) (front []byte, body []byte, err error) {
+	contents = bytes.TrimPrefix(contents, []byte("\ufeff"))
+
+	first, rest, _ := bytes.Cut(contents, []byte("\n"))
+	if !bytes.Equal(bytes.TrimSpace(first), frontMatterDelim) {
+		return nil, nil, ErrMissingFrontMatter
+	}
+
+	front = []byte("\n")
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		if bytes.Equal(bytes.TrimSpace(line), frontMatterDelim) {
+			return front, rest, nil
+		}
+		front = append(front, line...)
+		front = append(front, '\n')
+	}
+	return nil, nil, fmt.Errorf("unterminated front matter")
+}
+
+// Parses and validates the article front matter. Author handles must be present in authors.
+// All problems found are returned joined, each one as a [*FrontMatterError].
+func parseFrontMatter(path string, front []byte, authors map[string]bool) (ArticleMetadata, error) {
+	var metadata ArticleMetadata
+
+	fail := func(line int, format string, args ...any) error {
+		return &FrontMatterError{Path: path, Line: line, Err: fmt.Errorf(format, args...)}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(front, &doc); err != nil {
+		return metadata, fail(1, "%w", err)
+	}
+	if len(doc.Content) == 0 {
+		return metadata, fail(1, "empty front matter")
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return metadata, fail(root.Line, "front matter must be a mapping")
+	}
+
+	var (
+		errs []error
+		seen = make(map[string]bool)
+	)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		seen[key.Value] = true
+
+		var err error
+		switch key.Value {
+		case "title":
+			err = decodeString(value, &metadata.Title)
+			if err == nil && metadata.Title == "" {
+				err = errors.New("must not be blank")
+			}
+		case "subtitle":
+			err = decodeString(value, &metadata.Subtitle)
+		case "author", "authors":
+			var handles []*yaml.Node
+			handles, err = authorNodes(value)
+			for _, handle := range handles {
+				h := strings.TrimPrefix(handle.Value, "@")
+				if !authors[h] {
+					errs = append(errs, fail(handle.Line, "unknown author %q", handle.Value))
+				}
+				metadata.Authors = append(metadata.Authors, h)
+			}
+		case "image":
+			err = decodeString(value, &metadata.Image)
+			if err == nil && !validImageRef(metadata.Image) {
+				err = errors.New("expected an absolute http(s) URL or a path starting with /")
+			}
+		case "draft":
+			err = value.Decode(&metadata.Draft)
+			if err != nil {
+				err = errors.New("expected a boolean")
+			}
+		case "date":
+			metadata.Date, err = decodeDate(value)
+		case "publish_at":
+			metadata.PublishAt, err = decodeDate(value)
+		case "updated":
+			metadata.Updated, err = decodeDate(value)
+		case "changelog":
+			metadata.Changelog, err = decodeChangelog(value)
+		case "tags":
+			err = value.Decode(&metadata.Tags)
+			if err != nil {
+				err = errors.New("expected a list of strings")
+			}
+		default:
+			errs = append(errs, fail(key.Line, "unknown field %q", key.Value))
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fail(value.Line, "invalid %q: %w", key.Value, err))
+		}
+	}
+
+	// Problems with the front matter as a whole point to where its mapping starts.
+	for _, field := range []string{"title", "date"} {
+		if !seen[field] {
+			errs = append(errs, fail(root.Line, "missing required field %q", field))
+		}
+	}
+	switch {
+	case seen["author"] && seen["authors"]:
+		errs = append(errs, fail(root.Line, `use either "author" or "authors", not both`))
+	case !seen["author"] && !seen["authors"]:
+		errs = append(errs, fail(root.Line, `missing required field "author"`))
+	}
+
+	return metadata, errors.Join(errs...)
+}
+
+func decodeString(node *yaml.Node, dst *string) error {
+	if node.Kind != yaml.ScalarNode {
+		return errors.New("expected a string")
+	}
+	*dst = node.Value
+	return nil
+}
+
+// Reports whether ref is an absolute http(s) URL or an absolute path.
+func validImageRef(ref string) bool {
+	if strings.HasPrefix(ref, "/") && !strings.HasPrefix(ref, "//") {
+		return true
+	}
+	u, err := url.Parse(ref)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Returns the author handle nodes from either a single handle or a list of handles.
+func authorNodes(node *yaml.Node) ([]*yaml.Node, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []*yaml.Node{node}, nil
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			return nil, errors.New("expected at least one author handle")
+		}
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, errors.New("expected a list of author handles")
+			}
+		}
+		return node.Content, nil
+	default:
+		return nil, errors.New("expected an author handle or a list of handles")
+	}
+}
+
+func decodeDate(node *yaml.Node) (time.Time, error) {
+	if node.Kind != yaml.ScalarNode {
+		return time.Time{}, errors.New("expected a date")
+	}
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, node.Value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a YYYY-MM-DD or RFC 3339 date, got %q", node.Value)
+}
+
+func decodeChangelog(node *yaml.Node) ([]ChangelogEntry, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.New("expected a list of entries with date and note")
+	}
+
+	entries := make([]ChangelogEntry, 0, len(node.Content))
+	for _, item := range node.Content {
+		var raw struct {
+			Date string `yaml:"date"`
+			Note string `yaml:"note"`
+		}
+		if item.Kind != yaml.MappingNode || item.Decode(&raw) != nil {
+			return nil, fmt.Errorf("line %d: expected an entry with date and note", item.Line)
+		}
+		if raw.Note == "" {
+			return nil, fmt.Errorf("line %d: entry note must not be blank", item.Line)
+		}
+		date, err := decodeDate(&yaml.Node{Kind: yaml.ScalarNode, Value: raw.Date})
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", item.Line, err)
+		}
+		entries = append(entries, ChangelogEntry{Date: date, Note: raw.Note})
+	}
+	return entries, nil
+}
@@ -0,0 +1,175 @@
+package blog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"ffss.dev/internal/sqlite"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	authors := map[string]bool{"ffss": true}
+
+	// Each wanted error is the "file:line: message" prefix of one of the joined errors.
+	tests := []struct {
+		name  string
+		front string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			front: "title: Hello\ndate: 2024-12-01\nauthor: ffss\ntags: [go]\n",
+		},
+		{
+			name:  "unknown field",
+			front: "title: Hello\ndate: 2024-12-01\nauthor: ffss\ncategory: go\n",
+			want:  []string{`a.md:5: unknown field "category"`},
+		},
+		{
+			name:  "missing required fields",
+			front: "subtitle: Hello\n",
+			want: []string{
+				`a.md:2: missing required field "title"`,
+				`a.md:2: missing required field "date"`,
+				`a.md:2: missing required field "author"`,
+			},
+		},
+		{
+			name:  "missing fields after blank lines",
+			front: "\n# A comment\nsubtitle: Hello\ndate: 2024-12-01\nauthor: ffss\n",
+			want:  []string{`a.md:4: missing required field "title"`},
+		},
+		{
+			name:  "blank title",
+			front: "title: \"\"\ndate: 2024-12-01\nauthor: ffss\n",
+			want:  []string{`a.md:2: invalid "title": must not be blank`},
+		},
+		{
+			name:  "bad date",
+			front: "title: Hello\ndate: 01/12/2024\nauthor: ffss\n",
+			want:  []string{`a.md:3: invalid "date": expected a YYYY-MM-DD or RFC 3339 date`},
+		},
+		{
+			name:  "unknown author",
+			front: "title: Hello\ndate: 2024-12-01\nauthors: [ffss, nobody]\n",
+			want:  []string{`a.md:4: unknown author "nobody"`},
+		},
+		{
+			name:  "author and authors",
+			front: "title: Hello\ndate: 2024-12-01\nauthor: ffss\nauthors: [ffss]\n",
+			want:  []string{`a.md:2: use either "author" or "authors", not both`},
+		},
+		{
+			name:  "yaml syntax error",
+			front: "title: [Hello\ndate: 2024-12-01\n",
+			want:  []string{"a.md:1: yaml:"},
+		},
+		{
+			name:  "not a mapping",
+			front: "- title\n",
+			want:  []string{"a.md:2: front matter must be a mapping"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Front matter keeps a blank line in place of the opening delimiter.
+			_, err := parseFrontMatter("a.md", []byte("\n"+tt.front), authors)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("parseFrontMatter() error: %v", err)
+				}
+				return
+			}
+			if !isFrontMatterError(err) {
+				t.Fatalf("parseFrontMatter() = %v, want a *FrontMatterError", err)
+			}
+			got := strings.Split(err.Error(), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseFrontMatter() errors = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], tt.want[i]) {
+					t.Errorf("parseFrontMatter() error %d = %q, want prefix %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterFields(t *testing.T) {
+	front := "\ntitle: Hello\nsubtitle: World\ndate: 2024-12-01\nauthors: [\"@ffss\"]\ndraft: true\ntags: [go, sql]\n"
+	metadata, err := parseFrontMatter("a.md", []byte(front), map[string]bool{"ffss": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Title != "Hello" || metadata.Subtitle != "World" || !metadata.Draft {
+		t.Errorf("parseFrontMatter() = %+v", metadata)
+	}
+	if got := metadata.Date.Format("2006-01-02"); got != "2024-12-01" {
+		t.Errorf("Date = %s, want 2024-12-01", got)
+	}
+	if len(metadata.Authors) != 1 || metadata.Authors[0] != "ffss" {
+		t.Errorf("Authors = %q, want [ffss]", metadata.Authors)
+	}
+	if len(metadata.Tags) != 2 {
+		t.Errorf("Tags = %q, want [go sql]", metadata.Tags)
+	}
+}
+
+func TestSkipInvalid(t *testing.T) {
+	articles := fstest.MapFS{
+		"valid.md":   {Data: []byte("---\ntitle: Valid\ndate: 2024-12-01\nauthor: ffss\n---\n# Valid\n")},
+		"invalid.md": {Data: []byte("---\ntitle: Invalid\nauthor: ffss\n---\n# Invalid\n")},
+	}
+
+	db := newTestDB(t)
+	_, err := New(false, db, articles)
+	var fmErr *FrontMatterError
+	if !errors.As(err, &fmErr) || fmErr.Path != "invalid.md" {
+		t.Fatalf("New() = %v, want a *FrontMatterError for invalid.md", err)
+	}
+
+	s, err := New(false, db, articles, WithSkipInvalid(true))
+	if err != nil {
+		t.Fatalf("New() with skip invalid: %v", err)
+	}
+	if n := s.ArticleCount(); n != 1 {
+		t.Errorf("ArticleCount() = %d, want 1", n)
+	}
+	if _, err := s.GetArticle(context.Background(), "valid"); err != nil {
+		t.Errorf("GetArticle(valid) error: %v", err)
+	}
+}
+
+// Opens a database in a temporary dir with all migrations applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.Connect(context.Background(), filepath.Join(t.TempDir(), "blog.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	paths, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(b), "-- +goose Down")
+		if _, err := db.Exec(up); err != nil {
+			t.Fatalf("failed to apply %s: %v", path, err)
+		}
+	}
+	return db
+}
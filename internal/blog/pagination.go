@@ -0,0 +1,43 @@
+package blog
+
+// Holds the pagination state of a listing.
+type Pagination struct {
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// Returns the total number of pages. A listing without a page size always has a single page.
+func (p Pagination) TotalPages() int {
+	if p.PageSize <= 0 {
+		return 1
+	}
+	pages := (p.Total + p.PageSize - 1) / p.PageSize
+	return max(pages, 1)
+}
+
+func (p Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+func (p Pagination) HasNext() bool {
+	return p.Page < p.TotalPages()
+}
+
+func (p Pagination) PrevPage() int {
+	return p.Page - 1
+}
+
+func (p Pagination) NextPage() int {
+	return p.Page + 1
+}
+
+// Returns the [start, end) bounds of the current page for a slice of length total.
+func (p Pagination) bounds() (int, int) {
+	if p.PageSize <= 0 {
+		return 0, p.Total
+	}
+	start := min((p.Page-1)*p.PageSize, p.Total)
+	end := min(start+p.PageSize, p.Total)
+	return start, end
+}
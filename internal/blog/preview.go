@@ -0,0 +1,91 @@
+package blog
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidPreview = errors.New("invalid preview token")
+	ErrExpiredPreview = errors.New("expired preview token")
+)
+
+// Creates a preview token unlocking the article identified by slug until expires. The token
+// has the form base64(slug:expires).base64(hmac), both using the URL-safe alphabet.
+func SignPreview(secret []byte, slug string, expires time.Time) string {
+	payload := fmt.Sprintf("%s:%d", slug, expires.Unix())
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(previewMAC(secret, payload))
+}
+
+// Checks that token was signed with secret for slug and has not expired. Returns
+// [ErrInvalidPreview] or [ErrExpiredPreview] otherwise.
+func VerifyPreview(secret []byte, token, slug string, now time.Time) error {
+	if len(secret) == 0 {
+		return ErrInvalidPreview
+	}
+
+	enc := base64.RawURLEncoding
+	encPayload, encMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidPreview
+	}
+	payload, err := enc.DecodeString(encPayload)
+	if err != nil {
+		return ErrInvalidPreview
+	}
+	mac, err := enc.DecodeString(encMAC)
+	if err != nil {
+		return ErrInvalidPreview
+	}
+	if !hmac.Equal(mac, previewMAC(secret, string(payload))) {
+		return ErrInvalidPreview
+	}
+
+	// Slugs may contain ':', so the expiry is split from the right.
+	i := strings.LastIndexByte(string(payload), ':')
+	if i < 0 || string(payload[:i]) != slug {
+		return ErrInvalidPreview
+	}
+	expires, err := strconv.ParseInt(string(payload[i+1:]), 10, 64)
+	if err != nil {
+		return ErrInvalidPreview
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return ErrExpiredPreview
+	}
+	return nil
+}
+
+func previewMAC(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// Grabs an article by it's slug, ignoring draft and schedule visibility, if token is a valid
+// preview token for it. Returns [ErrInvalidPreview] or [ErrExpiredPreview] for bad tokens.
+func (s *Service) PreviewArticle(ctx context.Context, slug, token string) (_ *Article, err error) {
+	ctx, span := startSpan(ctx, "PreviewArticle", attribute.String("blog.article.slug", slug))
+	defer func() { endSpan(span, err) }()
+
+	if err := VerifyPreview(s.previewSecret, token, slug, time.Now()); err != nil {
+		return nil, err
+	}
+
+	article, err := s.getArticle(ctx, slug, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return article, nil
+}
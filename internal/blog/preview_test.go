@@ -0,0 +1,52 @@
+package blog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyPreview(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1_700_000_000, 0)
+	token := SignPreview(secret, "my-draft", now.Add(time.Hour))
+	payload, mac, _ := strings.Cut(token, ".")
+
+	// Flips the first character of s, keeping it in the base64 URL alphabet.
+	flip := func(s string) string {
+		if s[0] == 'A' {
+			return "B" + s[1:]
+		}
+		return "A" + s[1:]
+	}
+
+	tests := []struct {
+		name   string
+		secret []byte
+		token  string
+		slug   string
+		now    time.Time
+		want   error
+	}{
+		{name: "valid", secret: secret, token: token, slug: "my-draft", now: now},
+		{name: "slug with colon", secret: secret, token: SignPreview(secret, "a:b", now.Add(time.Hour)), slug: "a:b", now: now},
+		{name: "expired", secret: secret, token: token, slug: "my-draft", now: now.Add(time.Hour), want: ErrExpiredPreview},
+		{name: "wrong slug", secret: secret, token: token, slug: "other-draft", now: now, want: ErrInvalidPreview},
+		{name: "wrong secret", secret: []byte("other"), token: token, slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "tampered payload", secret: secret, token: flip(payload) + "." + mac, slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "tampered mac", secret: secret, token: payload + "." + flip(mac), slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "missing mac", secret: secret, token: payload, slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "invalid base64 payload", secret: secret, token: "!!!." + mac, slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "invalid base64 mac", secret: secret, token: payload + ".!!!", slug: "my-draft", now: now, want: ErrInvalidPreview},
+		{name: "empty secret", secret: nil, token: SignPreview(nil, "my-draft", now.Add(time.Hour)), slug: "my-draft", now: now, want: ErrInvalidPreview},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyPreview(tt.secret, tt.token, tt.slug, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyPreview() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package blog
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A single entry of the article changelog, declared in the front matter.
+type ChangelogEntry struct {
+	Date time.Time
+	Note string
+}
+
+// Records a new revision for slug if contents changed since the last recorded revision.
+// Returns the time of the last content change, or the zero time if the article has not
+// changed since it was first recorded.
+func (s *Service) recordRevision(slug string, contents []byte) (time.Time, error) {
+	sum := sha256.Sum256(contents)
+	hash := hex.EncodeToString(sum[:])
+
+	// Articles are parsed on every request in dev mode and are rarely changed, so unchanged
+	// contents are checked without opening a write transaction.
+	last, err := lastRevision(s.db.QueryRow, slug)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last.hash == hash {
+		return last.changedAt(), nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	// Read again, another parse may have recorded it in the meantime.
+	last, err = lastRevision(tx.QueryRow, slug)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if last.hash != hash {
+		last.hash, last.createdAt = hash, time.Now().UTC()
+		query := `
+		INSERT INTO article_revisions (slug, content_hash, created_at)
+		VALUES ($1, $2, $3)`
+		_, err = tx.Exec(query, slug, hash, last.createdAt)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("blog: failed to save revision: %w", err)
+		}
+		last.count++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+	return last.changedAt(), nil
+}
+
+type revision struct {
+	hash      string
+	createdAt time.Time
+	// Number of revisions recorded for the article.
+	count int
+}
+
+// Returns the time of the last content change, or the zero time if there is none. The first
+// revision is the original content, not an update.
+func (r revision) changedAt() time.Time {
+	if r.count <= 1 {
+		return time.Time{}
+	}
+	return r.createdAt
+}
+
+// Looks up the latest revision of slug using queryRow, from either the database or a
+// transaction. Returns a zero revision if none was recorded yet.
+func lastRevision(queryRow func(query string, args ...any) *sql.Row, slug string) (revision, error) {
+	var r revision
+	query := `
+	SELECT 
+		content_hash, 
+		created_at,
+		(SELECT COUNT(*) FROM article_revisions WHERE slug = $1)
+	FROM article_revisions
+	WHERE slug = $1
+	ORDER BY created_at DESC, id DESC
+	LIMIT 1`
+	err := queryRow(query, slug).Scan(&r.hash, &r.createdAt, &r.count)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return revision{}, fmt.Errorf("blog: failed to get revision: %w", err)
+	}
+	return r, nil
+}
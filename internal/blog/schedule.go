@@ -0,0 +1,54 @@
+package blog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Upper bound between visibility checks, in case the clock jumps.
+const maxScheduleWait = time.Hour
+
+// Re-evaluates article visibility whenever a scheduled article is due, re-indexing the
+// search contents so it becomes searchable. Listings and lookups are evaluated per request
+// and need no refresh. Blocks until ctx is cancelled. No-op in dev mode, where every
+// article is visible.
+func (s *Service) RunScheduler(ctx context.Context) {
+	if s.dev {
+		return
+	}
+
+	timer := time.NewTimer(s.nextScheduleWait(time.Now()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.mu.Lock()
+			err := s.indexContents()
+			s.mu.Unlock()
+			s.version.Add(1)
+			if err != nil {
+				s.log(ctx).Error("failed to index scheduled articles", slog.String("err", err.Error()))
+			}
+			timer.Reset(s.nextScheduleWait(time.Now()))
+		}
+	}
+}
+
+// Returns how long to wait until the next scheduled article is published.
+func (s *Service) nextScheduleWait(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := maxScheduleWait
+	for _, article := range s.cache {
+		if article.Draft || !article.IsScheduled(now) {
+			continue
+		}
+		wait = min(wait, article.PublishedAt().Sub(now))
+	}
+	return wait
+}
@@ -3,13 +3,18 @@ package blog
 import (
 	"context"
 	"strconv"
+	"time"
 )
 
 type SearchResult struct {
 	Articles []*ArticleResult `json:"articles"`
 }
 
-func (s *Service) Search(ctx context.Context, q string) (*SearchResult, error) {
+func (s *Service) Search(ctx context.Context, q string) (_ *SearchResult, err error) {
+	ctx, span := startSpan(ctx, "Search")
+	defer func() { endSpan(span, err) }()
+	defer func(t time.Time) { s.metrics.SearchDuration(time.Since(t)) }(time.Now())
+
 	articles, err := s.searchArticles(ctx, q)
 	if err != nil {
 		return nil, err
@@ -74,9 +79,10 @@ func (s *Service) indexContents() error {
 		return err
 	}
 
+	now := time.Now()
 	for _, article := range s.cache {
-		// Skip draft articles in prod
-		if article.Draft && !s.dev {
+		// Skip draft and scheduled articles in prod
+		if !s.isVisible(article, now) {
 			continue
 		}
 
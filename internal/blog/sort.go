@@ -13,7 +13,7 @@ func popularSort(a, b *Article) int {
 }
 
 func dateSort(a, b *Article) int {
-	res := strings.Compare(a.Date, b.Date)
+	res := a.Date.Compare(b.Date)
 	if res == 0 {
 		return strings.Compare(a.Title, b.Title)
 	}
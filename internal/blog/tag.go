@@ -0,0 +1,51 @@
+package blog
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Lists the tags of all visible articles with their article count, most used first. Tags are
+// grouped ignoring case, using the first spelling found.
+func (s *Service) ListTags(ctx context.Context) (_ []Tag, err error) {
+	ctx, span := startSpan(ctx, "ListTags")
+	defer func() { endSpan(span, err) }()
+
+	if err := s.refreshArticles(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	index := make(map[string]int)
+	tags := make([]Tag, 0)
+	for _, article := range s.cache {
+		if !s.isVisible(article, now) {
+			continue
+		}
+		for _, name := range article.Tags {
+			key := strings.ToLower(name)
+			i, ok := index[key]
+			if !ok {
+				i = len(tags)
+				index[key] = i
+				tags = append(tags, Tag{Name: name})
+			}
+			tags[i].Count++
+		}
+	}
+
+	slices.SortFunc(tags, func(a, b Tag) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return tags, nil
+}
@@ -0,0 +1,61 @@
+package blog
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// A table of contents entry, built from the article h2 and h3 headings.
+type Heading struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Level int    `json:"level"`
+}
+
+// Collects the h2 and h3 headings of a parsed markdown document.
+func tableOfContents(doc ast.Node, source []byte) []Heading {
+	toc := make([]Heading, 0)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if heading.Level == 2 || heading.Level == 3 {
+			var id string
+			if v, ok := heading.AttributeString("id"); ok {
+				if b, ok := v.([]byte); ok {
+					id = string(b)
+				}
+			}
+			toc = append(toc, Heading{
+				ID:    id,
+				Title: nodeText(heading, source),
+				Level: heading.Level,
+			})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return toc
+}
+
+// Concatenates the text of all descendants of n.
+func nodeText(n ast.Node, source []byte) string {
+	buf := new(bytes.Buffer)
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.String()
+}
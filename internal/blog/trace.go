@@ -0,0 +1,31 @@
+package blog
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("ffss.dev/internal/blog")
+
+// Starts a span for the [Service] method name. Must be ended with [endSpan].
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "blog."+name, trace.WithAttributes(attrs...))
+}
+
+// Ends span, marking it as failed if err is set. Lookups of missing resources are expected
+// and are not failures.
+func endSpan(span trace.Span, err error) {
+	notFound := errors.Is(err, ErrArticleNotFound) ||
+		errors.Is(err, ErrAuthorNotFound) ||
+		errors.Is(err, ErrAvatarNotFound)
+	if err != nil && !notFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
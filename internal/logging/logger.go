@@ -1,12 +1,16 @@
 package logging
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"github.com/lmittmann/tint"
+	"go.opentelemetry.io/otel/trace"
 )
 
+type loggerKey struct{}
+
 func NewLogger(dev bool) *slog.Logger {
 	var h slog.Handler
 	if dev {
@@ -14,5 +18,53 @@ func NewLogger(dev bool) *slog.Logger {
 	} else {
 		h = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})
 	}
-	return slog.New(h)
+	return slog.New(&traceHandler{Handler: h})
+}
+
+// Returns a copy of ctx carrying logger. See [FromContext].
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Returns the logger carried by ctx, usually scoped to a request, or fallback if there is none.
+// Records the logger writes without a context, e.g. through [slog.Logger.Info], get the trace
+// IDs of the span in ctx.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		logger = fallback
+	}
+	if h, ok := logger.Handler().(*traceHandler); ok && trace.SpanContextFromContext(ctx).IsValid() {
+		return slog.New(&traceHandler{Handler: h.Handler, ctx: ctx})
+	}
+	return logger
+}
+
+// Adds the trace_id and span_id of the span found in the record context.
+type traceHandler struct {
+	slog.Handler
+	// Used for records without a span in their context.
+	ctx context.Context
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() && h.ctx != nil {
+		sc = trace.SpanContextFromContext(h.ctx)
+	}
+	if sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithGroup(name), ctx: h.ctx}
 }
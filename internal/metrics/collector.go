@@ -7,56 +7,235 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Histogram buckets, in milliseconds unless noted otherwise.
+var (
+	httpDurationBuckets    = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+	queryDurationBuckets   = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250}
+	refreshDurationBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+	searchDurationBuckets  = []float64{0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500}
+	// In bytes, from 256B to 4MB.
+	responseSizeBuckets = prometheus.ExponentialBuckets(256, 4, 8)
+)
+
 type Collector struct {
-	logger          *slog.Logger
-	reg             *prometheus.Registry
-	httpReqTotal    *prometheus.CounterVec
-	httpReqDuration *prometheus.HistogramVec
+	logger                 *slog.Logger
+	reg                    *prometheus.Registry
+	mux                    *http.ServeMux
+	httpReqTotal           *prometheus.CounterVec
+	httpReqDuration        *prometheus.HistogramVec
+	httpReqInFlight        prometheus.Gauge
+	httpRespSize           *prometheus.HistogramVec
+	pageCacheHits          prometheus.Counter
+	pageCacheMisses        prometheus.Counter
+	rateLimited            *prometheus.CounterVec
+	queryDuration          *prometheus.HistogramVec
+	queryErrors            *prometheus.CounterVec
+	articleRefreshDuration prometheus.Histogram
+	searchDuration         prometheus.Histogram
+	articleViews           *prometheus.CounterVec
+	articles               prometheus.Gauge
+	buildInfo              *prometheus.GaugeVec
 }
 
 func NewCollector(logger *slog.Logger) *Collector {
 	col := &Collector{
 		logger: logger.With(slog.String("name", "metrics")),
 		reg:    prometheus.NewRegistry(),
+		mux:    http.NewServeMux(),
 		httpReqTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace: "blog",
 			Name:      "http_requests_total",
 			Help:      "Total number of HTTP requests.",
 		},
-			[]string{"method", "endpoint", "status"},
+			[]string{"method", "route", "status"},
 		),
 		httpReqDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "blog",
 			Name:      "request_duration_ms",
 			Help:      "Histogram of request duration in milliseconds.",
-			Buckets:   prometheus.DefBuckets,
+			Buckets:   httpDurationBuckets,
+		},
+			[]string{"method", "route"},
+		),
+		httpReqInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "blog",
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests being served.",
+		}),
+		httpRespSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "blog",
+			Name:      "response_size_bytes",
+			Help:      "Histogram of response body size in bytes, after compression.",
+			Buckets:   responseSizeBuckets,
+		},
+			[]string{"method", "route"},
+		),
+		pageCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "blog",
+			Name:      "page_cache_hits_total",
+			Help:      "Total number of rendered pages served from the page cache.",
+		}),
+		pageCacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "blog",
+			Name:      "page_cache_misses_total",
+			Help:      "Total number of page cache lookups that required rendering.",
+		}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blog",
+			Name:      "rate_limited_requests_total",
+			Help:      "Total number of requests rejected by rate limiting.",
+		},
+			[]string{"group"},
+		),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "blog",
+			Name:      "sqlite_query_duration_ms",
+			Help:      "Histogram of SQLite statement duration in milliseconds.",
+			Buckets:   queryDurationBuckets,
 		},
-			[]string{"method", "endpoint"},
+			[]string{"op"},
+		),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blog",
+			Name:      "sqlite_query_errors_total",
+			Help:      "Total number of failed SQLite statements.",
+		},
+			[]string{"op"},
+		),
+		articleRefreshDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "blog",
+			Name:      "article_refresh_duration_ms",
+			Help:      "Histogram of the time taken to parse and index all articles in milliseconds.",
+			Buckets:   refreshDurationBuckets,
+		}),
+		searchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "blog",
+			Name:      "search_duration_ms",
+			Help:      "Histogram of article search duration in milliseconds.",
+			Buckets:   searchDurationBuckets,
+		}),
+		articleViews: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "blog",
+			Name:      "article_views_total",
+			Help:      "Total number of article page views.",
+		},
+			[]string{"slug"},
+		),
+		articles: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "blog",
+			Name:      "articles",
+			Help:      "Number of articles loaded, including drafts and scheduled articles.",
+		}),
+		buildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "blog",
+			Name:      "build_info",
+			Help:      "Always 1, labeled with the VCS revision and commit time the server was built from.",
+		},
+			[]string{"revision", "vcs_time", "modified", "go_version"},
 		),
 	}
 	col.reg.MustRegister(col.httpReqTotal)
 	col.reg.MustRegister(col.httpReqDuration)
+	col.reg.MustRegister(col.pageCacheHits)
+	col.reg.MustRegister(col.pageCacheMisses)
+	col.reg.MustRegister(col.rateLimited)
+	col.reg.MustRegister(col.httpReqInFlight)
+	col.reg.MustRegister(col.httpRespSize)
+	col.reg.MustRegister(col.queryDuration)
+	col.reg.MustRegister(col.queryErrors)
+	col.reg.MustRegister(col.articleRefreshDuration)
+	col.reg.MustRegister(col.searchDuration)
+	col.reg.MustRegister(col.articleViews)
+	col.reg.MustRegister(col.articles)
+	col.reg.MustRegister(col.buildInfo)
+	col.reg.MustRegister(collectors.NewGoCollector())
+	col.reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
 	return col
 }
 
 func (c *Collector) ServeMetrics(addr string) {
-	mux := http.NewServeMux()
-	mux.Handle("GET /metrics", promhttp.HandlerFor(c.reg, promhttp.HandlerOpts{}))
+	c.mux.Handle("GET /metrics", promhttp.HandlerFor(c.reg, promhttp.HandlerOpts{}))
 
 	c.logger.Info("starting metrics server", slog.String("addr", addr))
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	if err := http.ListenAndServe(addr, c.mux); err != nil {
 		c.logger.Error("failed to start metrics server", slog.String("err", err.Error()))
 	}
 }
 
-func (c *Collector) IncHTTPRequest(method, url string, statusCode int) {
-	c.httpReqTotal.WithLabelValues(method, url, strconv.Itoa(statusCode)).Inc()
+// Registers an internal endpoint on the metrics server, for details not meant to be public.
+// May be called after the server started.
+func (c *Collector) Handle(pattern string, handler http.Handler) {
+	c.mux.Handle(pattern, handler)
+}
+
+// Converts d to fractional milliseconds.
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Route is the matched route pattern, never the raw path, to keep the number of series bounded.
+func (c *Collector) IncHTTPRequest(method, route string, statusCode int) {
+	c.httpReqTotal.WithLabelValues(method, route, strconv.Itoa(statusCode)).Inc()
+}
+
+func (c *Collector) RequestDuration(method, route string, duration time.Duration) {
+	c.httpReqDuration.WithLabelValues(method, route).Observe(ms(duration))
+}
+
+func (c *Collector) IncRequestsInFlight() {
+	c.httpReqInFlight.Inc()
+}
+
+func (c *Collector) DecRequestsInFlight() {
+	c.httpReqInFlight.Dec()
+}
+
+func (c *Collector) ResponseSize(method, route string, size int64) {
+	c.httpRespSize.WithLabelValues(method, route).Observe(float64(size))
+}
+
+func (c *Collector) IncPageCacheHit() {
+	c.pageCacheHits.Inc()
+}
+
+func (c *Collector) IncPageCacheMiss() {
+	c.pageCacheMisses.Inc()
+}
+
+func (c *Collector) IncRateLimited(group string) {
+	c.rateLimited.WithLabelValues(group).Inc()
+}
+
+func (c *Collector) QueryDuration(op string, d time.Duration) {
+	c.queryDuration.WithLabelValues(op).Observe(ms(d))
+}
+
+func (c *Collector) IncQueryError(op string) {
+	c.queryErrors.WithLabelValues(op).Inc()
+}
+
+func (c *Collector) ArticleRefreshDuration(d time.Duration) {
+	c.articleRefreshDuration.Observe(ms(d))
+}
+
+func (c *Collector) SearchDuration(d time.Duration) {
+	c.searchDuration.Observe(ms(d))
+}
+
+// Slug must be of an existing article, to keep the number of series bounded.
+func (c *Collector) IncArticleView(slug string) {
+	c.articleViews.WithLabelValues(slug).Inc()
+}
+
+func (c *Collector) SetArticleCount(n int) {
+	c.articles.Set(float64(n))
 }
 
-func (c *Collector) RequestDuration(method, endpoint string, duration time.Duration) {
-	c.httpReqDuration.WithLabelValues(method, endpoint).Observe(float64(duration.Milliseconds()))
+func (c *Collector) SetBuildInfo(revision, vcsTime string, modified bool, goVersion string) {
+	c.buildInfo.WithLabelValues(revision, vcsTime, strconv.FormatBool(modified), goVersion).Set(1)
 }
@@ -3,14 +3,36 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"net/url"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	msqlite "modernc.org/sqlite"
 )
 
-func Connect(ctx context.Context, dbPath string) (*sql.DB, error) {
+type options struct {
+	metrics Metrics
+}
+
+type Option func(*options)
+
+// Reports the duration and errors of every statement to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+func Connect(ctx context.Context, dbPath string, opts ...Option) (*sql.DB, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -20,10 +42,24 @@ func Connect(ctx context.Context, dbPath string) (*sql.DB, error) {
 	q.Set("_pragma", "foreign_keys(ON)")
 	q.Set("_pragma", "synchronous(NORMAL)")
 
-	db, err := sql.Open("sqlite", fmt.Sprintf("%s?%s", dbPath, q.Encode()))
-	if err != nil {
-		return nil, err
+	// Statements are traced as children of the caller span. Statements run outside of a trace,
+	// e.g. on startup, are not traced.
+	connector := &metricsConnector{
+		driver: &msqlite.Driver{},
+		dsn:    fmt.Sprintf("%s?%s", dbPath, q.Encode()),
+		m:      o.metrics,
 	}
+	db := otelsql.OpenDB(
+		connector,
+		otelsql.WithAttributes(semconv.DBSystemNameSQLite),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitRows:             true,
+			OmitConnResetSession: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
 	db.SetMaxIdleConns(1)
 	db.SetMaxOpenConns(1)
 
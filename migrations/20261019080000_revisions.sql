@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE "article_revisions" (
+    "id" INTEGER PRIMARY KEY,
+    "slug" TEXT NOT NULL,
+    "content_hash" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL
+);
+CREATE INDEX "idx_article_revisions_slug" ON "article_revisions"("slug", "created_at");
+
+-- +goose Down
+DROP TABLE "article_revisions";
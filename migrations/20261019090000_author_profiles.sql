@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE "authors" ADD COLUMN "pronouns" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "authors" ADD COLUMN "location" TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE "author_links" (
+    "id" INTEGER PRIMARY KEY,
+    "author_id" INTEGER NOT NULL REFERENCES "authors"("id") ON DELETE CASCADE,
+    "kind" TEXT NOT NULL,
+    "url" TEXT NOT NULL,
+    "position" INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX "idx_author_links_author_id" ON "author_links"("author_id", "position");
+
+-- +goose Down
+DROP TABLE "author_links";
+ALTER TABLE "authors" DROP COLUMN "location";
+ALTER TABLE "authors" DROP COLUMN "pronouns";
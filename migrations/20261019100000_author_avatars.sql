@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE "author_avatars" (
+    "handle" TEXT NOT NULL,
+    "size" INTEGER NOT NULL,
+    "source_url" TEXT NOT NULL,
+    "hash" TEXT NOT NULL,
+    "data" BLOB NOT NULL,
+    "updated_at" TIMESTAMP NOT NULL,
+    PRIMARY KEY ("handle", "size")
+);
+
+-- +goose Down
+DROP TABLE "author_avatars";
@@ -13,6 +13,9 @@
 export async function search(q) {
   const query = new URLSearchParams({ q });
   const res = await fetch(`/api/search?${query.toString()}`);
+  if (!res.ok) {
+    throw new Error(`search failed with status ${res.status}`);
+  }
   const data = await res.json();
   return data;
 }